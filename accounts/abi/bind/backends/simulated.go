@@ -37,6 +37,7 @@ import (
 	"github.com/odf/go-odf/core/state"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/internal/odfapi"
 	"github.com/odf/go-odf/odf/filters"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
@@ -545,7 +546,7 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call odf.CallMsg, b
 	evmContext := core.NewEVMContext(msg, block.Header(), b.blockchain, nil)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
-	vmEnv := vm.NewEVM(evmContext, stateDB, b.config, vm.Config{})
+	vmEnv := vm.NewEVM(evmContext, stateDB, b.config, vm.Config{NoBaseFee: true})
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.NewStateTransition(vmEnv, msg, gasPool).TransitionDb()
@@ -557,7 +558,7 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	sender, err := types.Sender(types.NewEIP155Signer(b.config.ChainID), tx)
+	sender, err := types.Sender(types.LatestSigner(b.config), tx)
 	if err != nil {
 		panic(fmt.Errorf("invalid transaction: %v", err))
 	}
@@ -713,6 +714,23 @@ func (m callMsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callMsg) Gas() uint64          { return m.CallMsg.Gas }
 func (m callMsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callMsg) Data() []byte         { return m.CallMsg.Data }
+func (m callMsg) AccessList() types.AccessList {
+	return m.CallMsg.AccessList
+}
+
+func (m callMsg) GasFeeCap() *big.Int {
+	if m.CallMsg.GasFeeCap == nil {
+		return m.CallMsg.GasPrice
+	}
+	return m.CallMsg.GasFeeCap
+}
+
+func (m callMsg) GasTipCap() *big.Int {
+	if m.CallMsg.GasTipCap == nil {
+		return m.CallMsg.GasPrice
+	}
+	return m.CallMsg.GasTipCap
+}
 
 // filterBackend implements filters.Backend to support filtering for logs without
 // taking bloom-bits acceleration structures into account.
@@ -721,8 +739,13 @@ type filterBackend struct {
 	bc *core.BlockChain
 }
 
-func (fb *filterBackend) ChainDb() odfdb.Database  { return fb.db }
-func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
+func (fb *filterBackend) ChainDb() odfdb.Database          { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux         { panic("not supported") }
+func (fb *filterBackend) ChainConfig() *params.ChainConfig { return fb.bc.Config() }
+
+func (fb *filterBackend) RPCPendingTransaction(tx *types.Transaction) interface{} {
+	return odfapi.NewRPCPendingTransaction(tx)
+}
 
 func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
 	if block == rpc.LatestBlockNumber {
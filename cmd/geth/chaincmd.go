@@ -41,6 +41,29 @@ import (
 	"gopkg.in/urfave/cli.v1"
 )
 
+var (
+	inspectJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the inspection report as JSON",
+	}
+	inspectPrefixFlag = cli.StringFlag{
+		Name:  "prefix",
+		Usage: "Only inspect the keys with a hex prefix or of a data type (snapshot, preimages, bloombits, code, txlookup)",
+	}
+	inspectTopFlag = cli.IntFlag{
+		Name:  "top",
+		Usage: "Number of largest entries to report per category",
+	}
+	inspectSampleFlag = cli.Float64Flag{
+		Name:  "sample",
+		Usage: "Fraction of the key space to scan, estimating the sizes from it (0 = full scan)",
+	}
+	inspectHistogramFlag = cli.BoolFlag{
+		Name:  "histogram",
+		Usage: "Print the entry size histograms of the categories",
+	}
+)
+
 var (
 	initCommand = cli.Command{
 		Action:    utils.MigrateFlags(initGenesis),
@@ -209,6 +232,7 @@ Use "odf dump 0" to dump the genesis block.`,
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.CacheFlag,
 			utils.RopstenFlag,
 			utils.RinkebyFlag,
@@ -216,8 +240,21 @@ Use "odf dump 0" to dump the genesis block.`,
 			utils.YoloV1Flag,
 			utils.LegacyTestnetFlag,
 			utils.SyncModeFlag,
+			inspectJSONFlag,
+			inspectPrefixFlag,
+			inspectTopFlag,
+			inspectSampleFlag,
+			inspectHistogramFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The inspect command traverses the database and reports the total size and the
+number of entries of each data category. The report can be restricted to the
+keys with a given prefix, extended with entry size histograms and the largest
+entries of each category, and printed as JSON for further processing.
+
+For very large databases, --sample scans only the given fraction of the key
+space and extrapolates the sizes of the key-value store from it.`,
 	}
 )
 
@@ -463,7 +500,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(0, chainDb, syncBloom, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := rawdb.NewLevelDBDatabaseWithFreezer(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "")
+	db, err := rawdb.NewDiskDatabaseWithFreezer("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, ctx.Args().Get(1), "", true)
 	if err != nil {
 		return err
 	}
@@ -597,13 +634,26 @@ func dump(ctx *cli.Context) error {
 }
 
 func inspect(ctx *cli.Context) error {
+	config := &rawdb.InspectConfig{
+		Top:       ctx.Int(inspectTopFlag.Name),
+		Sample:    ctx.Float64(inspectSampleFlag.Name),
+		Histogram: ctx.Bool(inspectHistogramFlag.Name),
+		JSON:      ctx.Bool(inspectJSONFlag.Name),
+	}
+	if ctx.IsSet(inspectPrefixFlag.Name) {
+		prefixes, err := parsePrefixes(ctx.String(inspectPrefixFlag.Name))
+		if err != nil {
+			return err
+		}
+		config.Prefixes = prefixes
+	}
 	node, _ := makeConfigNode(ctx)
 	defer node.Close()
 
 	_, chainDb := utils.MakeChain(ctx, node, true)
 	defer chainDb.Close()
 
-	return rawdb.InspectDatabase(chainDb)
+	return rawdb.InspectDatabase(chainDb, config)
 }
 
 // hashish returns true for strings that look like hashes.
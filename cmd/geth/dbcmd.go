@@ -0,0 +1,213 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of go-odf.
+//
+// go-odf is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-odf is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-odf. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/odf/go-odf/cmd/utils"
+	"github.com/odf/go-odf/common/hexutil"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/log"
+	"github.com/odf/go-odf/trie"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:        "db",
+		Usage:       "Low level database operations",
+		ArgsUsage:   "",
+		Category:    "DATABASE COMMANDS",
+		Description: "",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export a range of raw database entries into a dump file",
+				ArgsUsage: "<type|hex prefix> <dumpfile> [<hex start>]",
+				Action:    utils.MigrateFlags(exportDatabase),
+				Category:  "DATABASE COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.YoloV1Flag,
+					utils.LegacyTestnetFlag,
+				},
+				Description: `
+godf db export <type|hex prefix> <dumpfile> [<hex start>]
+exports all the database entries whose keys start with the given prefix into
+a dump file of length-prefixed key-value pairs. The prefix is either a 0x
+prefixed hex string or one of the known data types: snapshot, preimages,
+bloombits, code or txlookup. If the dump file ends with .gz, the output is
+gzipped.
+
+An interrupted export logs the database key it stopped at, which can be passed
+as the optional start argument to continue the export into a new file. Any
+prefixes of the data type exported before that key are skipped.
+`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import raw database entries from a dump file",
+				ArgsUsage: "<dumpfile>",
+				Action:    utils.MigrateFlags(importDatabase),
+				Category:  "DATABASE COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.YoloV1Flag,
+					utils.LegacyTestnetFlag,
+				},
+				Description: `
+godf db import <dumpfile>
+imports the entries of a dump file created by "godf db export" into the
+database, overwriting any existing entries with the same keys.
+`,
+			},
+			{
+				Name:      "verify-ancients",
+				Usage:     "Verify the integrity of the ancient block store",
+				ArgsUsage: "[<start>]",
+				Action:    utils.MigrateFlags(verifyAncients),
+				Category:  "DATABASE COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.DBEngineFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.RopstenFlag,
+					utils.RinkebyFlag,
+					utils.GoerliFlag,
+					utils.YoloV1Flag,
+					utils.LegacyTestnetFlag,
+				},
+				Description: `
+godf db verify-ancients [<start>]
+scans all the tables of the ancient store (headers, hashes, bodies, receipts
+and total difficulties) from the given block number onwards (default = 0). The
+items are checked against their stored checksums (if the tables have them) and
+against the roots and hashes of their block headers, and the corrupted ranges
+are reported.
+
+Item checksums are only stored by tables created with them enabled (the default
+unless --datadir.ancient.nochecksums is set). Tables created by older versions
+keep their format and are only cross-checked against the block headers, a warning
+lists them on startup. Resync the ancient store to add checksums to them.
+
+A corrupted ancient store can't be repaired in place, remove it with
+'godf removedb' and resynchronize the chain.
+`,
+			},
+		},
+	}
+)
+
+// parsePrefixes resolves a database export range given either by the name of
+// a known data type or as a hex encoded key prefix.
+func parsePrefixes(arg string) ([][]byte, error) {
+	if prefixes, ok := rawdb.DataPrefixes(arg); ok {
+		return prefixes, nil
+	}
+	if !strings.HasPrefix(arg, "0x") {
+		return nil, fmt.Errorf("unknown data type %q", arg)
+	}
+	prefix, err := hexutil.Decode(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prefix %q: %v", arg, err)
+	}
+	return [][]byte{prefix}, nil
+}
+
+func exportDatabase(ctx *cli.Context) error {
+	if ctx.NArg() < 2 || ctx.NArg() > 3 {
+		return errors.New("this command requires two or three arguments")
+	}
+	prefixes, err := parsePrefixes(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	var start []byte
+	if ctx.NArg() == 3 {
+		if start, err = hexutil.Decode(ctx.Args().Get(2)); err != nil {
+			return fmt.Errorf("invalid start position: %v", err)
+		}
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	return utils.ExportDatabase(db, ctx.Args().Get(1), prefixes, start)
+}
+
+func importDatabase(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("this command requires an argument")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	return utils.ImportDatabase(db, ctx.Args().First())
+}
+
+func verifyAncients(ctx *cli.Context) error {
+	if ctx.NArg() > 1 {
+		return errors.New("this command accepts at most one argument")
+	}
+	var start uint64
+	if ctx.NArg() == 1 {
+		number, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid start block: %v", err)
+		}
+		start = number
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	corruptions, err := rawdb.VerifyAncients(db, trie.NewStackTrie(nil), start)
+	if err != nil {
+		return err
+	}
+	if len(corruptions) == 0 {
+		log.Info("Ancient store is intact")
+		return nil
+	}
+	for _, c := range corruptions {
+		log.Error("Corrupted ancient items", "table", c.Table, "from", c.From, "to", c.To, "err", c.Err)
+	}
+	return fmt.Errorf("ancient store corrupted from block %d, remove it with 'godf removedb' and resync the chain", corruptions[0].From)
+}
@@ -65,6 +65,8 @@ var (
 		utils.LegacyBootnodesV5Flag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.DBEngineFlag,
+		utils.AncientNoChecksumsFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
@@ -81,6 +83,8 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolPoolJournalFlag,
+		utils.TxPoolPoolJournalSlotsFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
@@ -93,6 +97,7 @@ var (
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.HistoryBodiesFlag,
 		utils.LightServeFlag,
 		utils.LegacyLightServFlag,
 		utils.LightIngressFlag,
@@ -175,6 +180,8 @@ var (
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
 		utils.HTTPApiFlag,
+		utils.HTTPAllowFlag,
+		utils.HTTPDenyFlag,
 		utils.LegacyRPCApiFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
@@ -182,14 +189,29 @@ var (
 		utils.WSPortFlag,
 		utils.LegacyWSPortFlag,
 		utils.WSApiFlag,
+		utils.WSAllowFlag,
+		utils.WSDenyFlag,
 		utils.LegacyWSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.LegacyWSAllowedOriginsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
+		utils.IPCAllowFlag,
+		utils.IPCDenyFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.AuthRPCEnabledFlag,
+		utils.AuthRPCListenAddrFlag,
+		utils.AuthRPCPortFlag,
+		utils.AuthRPCVirtualHostsFlag,
+		utils.AuthRPCApiFlag,
+		utils.AuthRPCJWTSecretFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateBurstFlag,
+		utils.RPCSlowCallFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -242,6 +264,10 @@ func init() {
 		makedagCommand,
 		versionCommand,
 		licenseCommand,
+		// See snapshot.go
+		snapshotCommand,
+		// See dbcmd.go
+		dbCommand,
 		// See config.go
 		dumpConfigCommand,
 		// See retestodf.go
@@ -420,7 +420,7 @@ func (api *RetestodfAPI) SetChainParams(ctx context.Context, chainParams ChainPa
 
 func (api *RetestodfAPI) SendRawTransaction(ctx context.Context, rawTx hexutil.Bytes) (common.Hash, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
 		// Return nil is not by mistake - some tests include sending transaction where gasLimit overflows uint64
 		return common.Hash{}, nil
 	}
@@ -673,7 +673,7 @@ func (api *RetestodfAPI) AccountRange(ctx context.Context,
 		signer := types.MakeSigner(api.blockchain.Config(), block.Number())
 		for idx, tx := range block.Transactions() {
 			// Assemble the transaction call message and return if the requested offset
-			msg, _ := tx.AsMessage(signer)
+			msg, _ := tx.AsMessage(signer, block.BaseFee())
 			context := core.NewEVMContext(msg, block.Header(), api.blockchain, nil)
 			// Not yet the searched for transaction, execute on top of the current state
 			vmenv := vm.NewEVM(context, statedb, api.blockchain.Config(), vm.Config{})
@@ -783,7 +783,7 @@ func (api *RetestodfAPI) StorageRangeAt(ctx context.Context,
 		signer := types.MakeSigner(api.blockchain.Config(), block.Number())
 		for idx, tx := range block.Transactions() {
 			// Assemble the transaction call message and return if the requested offset
-			msg, _ := tx.AsMessage(signer)
+			msg, _ := tx.AsMessage(signer, block.BaseFee())
 			context := core.NewEVMContext(msg, block.Header(), api.blockchain, nil)
 			// Not yet the searched for transaction, execute on top of the current state
 			vmenv := vm.NewEVM(context, statedb, api.blockchain.Config(), vm.Config{})
@@ -18,7 +18,11 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -26,8 +30,10 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/core"
 	"github.com/odf/go-odf/core/rawdb"
 	"github.com/odf/go-odf/core/types"
@@ -43,6 +49,12 @@ const (
 	importBatchSize = 2500
 )
 
+// exportMagic is the header of the raw database export files, followed by the
+// format version and a stream of length-prefixed key-value pairs.
+var exportMagic = []byte("odfdbexp")
+
+const exportVersion = 1
+
 // Fatalf formats a message to standard error and exits the program.
 // The message is also printed to standard output if standard error
 // is redirected to a different file.
@@ -312,3 +324,240 @@ func ExportPreimages(db odfdb.Database, fn string) error {
 	log.Info("Exported preimages", "file", fn)
 	return nil
 }
+
+// exportWriter is a key-value writer streaming the entries into a raw database
+// export file, each key and value prefixed by its uvarint encoded length.
+type exportWriter struct {
+	w   io.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+// writeBlob writes a single length-prefixed blob into the export stream.
+func (w *exportWriter) writeBlob(blob []byte) error {
+	n := binary.PutUvarint(w.buf[:], uint64(len(blob)))
+	if _, err := w.w.Write(w.buf[:n]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(blob)
+	return err
+}
+
+// Put appends the key-value pair to the export stream.
+func (w *exportWriter) Put(key []byte, value []byte) error {
+	if err := w.writeBlob(key); err != nil {
+		return err
+	}
+	return w.writeBlob(value)
+}
+
+// Delete is not supported by the export stream.
+func (w *exportWriter) Delete(key []byte) error {
+	return errors.New("deletion not supported in exports")
+}
+
+// ExportDatabase exports all the database entries with the given key prefixes
+// into the specified file, truncating any data already present in the file.
+//
+// The optional start position is the full database key an interrupted export
+// logged as its resume position. The prefixes preceding the one it belongs to
+// are skipped, and the export continues from the key onwards, allowing it to
+// be resumed into a new file.
+func ExportDatabase(db odfdb.Database, fn string, prefixes [][]byte, start []byte) error {
+	// Locate the prefix to resume from before touching the output file
+	first := 0
+	if start != nil {
+		for first = 0; first < len(prefixes); first++ {
+			if bytes.HasPrefix(start, prefixes[first]) {
+				break
+			}
+		}
+		if first == len(prefixes) {
+			return fmt.Errorf("resume position %x outside of the exported prefixes", start)
+		}
+	}
+	// Watch for Ctrl-C while the export is running.
+	// If a signal is received, the export will stop at the next batch.
+	interrupt := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during export, stopping at next batch")
+		}
+		close(stop)
+	}()
+
+	log.Info("Exporting database", "file", fn)
+
+	// Open the file handle and potentially wrap with a gzip stream
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var (
+		buffer           = bufio.NewWriter(fh)
+		writer io.Writer = buffer
+		zipper *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		zipper = gzip.NewWriter(writer)
+		writer = zipper
+	}
+	if _, err := writer.Write(exportMagic); err != nil {
+		return err
+	}
+	var version [binary.MaxVarintLen64]byte
+	if _, err := writer.Write(version[:binary.PutUvarint(version[:], exportVersion)]); err != nil {
+		return err
+	}
+	// Stream all the requested ranges into the export file
+	var (
+		output    = &exportWriter{w: writer}
+		exportErr error
+		total     uint64
+		begin     = time.Now()
+		logged    = time.Now()
+	)
+	interrupted := func(prefix []byte, next []byte) bool {
+		select {
+		case <-stop:
+			resume := append(common.CopyBytes(prefix), next...)
+			log.Warn("Database export interrupted", "prefix", fmt.Sprintf("%x", prefix), "resume", hexutil.Encode(resume))
+			return true
+		default:
+			return false
+		}
+	}
+	for i := first; i < len(prefixes) && exportErr == nil; i++ {
+		prefix := prefixes[i]
+		if interrupted(prefix, nil) {
+			exportErr = errors.New("export interrupted")
+			break
+		}
+		var from []byte
+		if i == first && start != nil {
+			from = start[len(prefix):]
+		}
+		count, err := odfdb.CopyRange(output, db, prefix, from, func(count uint64, next []byte) error {
+			if next == nil {
+				return nil // Range done, the next one picks up any interruption
+			}
+			if interrupted(prefix, next) {
+				return errors.New("export interrupted")
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Exporting database", "prefix", fmt.Sprintf("%x", prefix), "entries", total+count, "elapsed", common.PrettyDuration(time.Since(begin)))
+				logged = time.Now()
+			}
+			return nil
+		})
+		total += count
+		exportErr = err
+	}
+	// Flush everything to disk even if interrupted, so the file is complete up
+	// to the resume position. Failures here mean the export is incomplete.
+	if zipper != nil {
+		if err := zipper.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	if exportErr != nil {
+		return exportErr
+	}
+	log.Info("Exported database", "file", fn, "entries", total, "elapsed", common.PrettyDuration(time.Since(begin)))
+	return nil
+}
+
+// ImportDatabase imports a raw database export into the database, overwriting
+// any entries already present with the same keys.
+func ImportDatabase(db odfdb.Database, fn string) error {
+	log.Info("Importing database", "file", fn)
+
+	// Open the file handle and potentially unwrap the gzip stream
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	stream := bufio.NewReader(reader)
+
+	// Ensure the file is a database export of a known version
+	magic := make([]byte, len(exportMagic))
+	if _, err := io.ReadFull(stream, magic); err != nil || !bytes.Equal(magic, exportMagic) {
+		return errors.New("not a database export file")
+	}
+	if version, err := binary.ReadUvarint(stream); err != nil || version != exportVersion {
+		return fmt.Errorf("unsupported database export version %d", version)
+	}
+	readBlob := func() ([]byte, error) {
+		size, err := binary.ReadUvarint(stream)
+		if err != nil {
+			return nil, err
+		}
+		blob := make([]byte, size)
+		if _, err := io.ReadFull(stream, blob); err != nil {
+			return nil, err
+		}
+		return blob, nil
+	}
+	// Import the entries in batches to prevent disk trashing
+	var (
+		batch  = db.NewBatch()
+		count  uint64
+		begin  = time.Now()
+		logged = time.Now()
+	)
+	for {
+		key, err := readBlob()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		value, err := readBlob()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		count++
+
+		if batch.ValueSize() >= odfdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Importing database", "entries", count, "elapsed", common.PrettyDuration(time.Since(begin)))
+			logged = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Imported database", "file", fn, "entries", count, "elapsed", common.PrettyDuration(time.Since(begin)))
+	return nil
+}
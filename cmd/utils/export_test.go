@@ -0,0 +1,90 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/odf/go-odf/core/rawdb"
+)
+
+// Tests that database exports round trip through imports, and that resuming an
+// export skips the prefixes that were already exported.
+func TestExportDatabaseResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db-export-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := rawdb.NewMemoryDatabase()
+	prefixes := [][]byte{[]byte("b"), []byte("a")}
+	for _, prefix := range prefixes {
+		for i := byte(0); i < 10; i++ {
+			src.Put(append(append([]byte{}, prefix...), i), []byte{i})
+		}
+	}
+	src.Put([]byte("c"), []byte("unrelated"))
+
+	for _, name := range []string{"export", "export.gz"} {
+		// Export everything and check it round trips
+		fn := filepath.Join(dir, name)
+		if err := ExportDatabase(src, fn, prefixes, nil); err != nil {
+			t.Fatalf("%s: failed to export: %v", name, err)
+		}
+		dst := rawdb.NewMemoryDatabase()
+		if err := ImportDatabase(dst, fn); err != nil {
+			t.Fatalf("%s: failed to import: %v", name, err)
+		}
+		for _, prefix := range prefixes {
+			for i := byte(0); i < 10; i++ {
+				key := append(append([]byte{}, prefix...), i)
+				if val, _ := dst.Get(key); !bytes.Equal(val, []byte{i}) {
+					t.Errorf("%s: key %x: value mismatch: have %x, want %x", name, key, val, []byte{i})
+				}
+			}
+		}
+		if ok, _ := dst.Has([]byte("c")); ok {
+			t.Errorf("%s: unrelated key exported", name)
+		}
+		// Resume from the middle of the second prefix
+		if err := ExportDatabase(src, fn, prefixes, []byte{'a', 5}); err != nil {
+			t.Fatalf("%s: failed to resume export: %v", name, err)
+		}
+		dst = rawdb.NewMemoryDatabase()
+		if err := ImportDatabase(dst, fn); err != nil {
+			t.Fatalf("%s: failed to import resumed export: %v", name, err)
+		}
+		for _, prefix := range prefixes {
+			for i := byte(0); i < 10; i++ {
+				key := append(append([]byte{}, prefix...), i)
+				want := prefix[0] == 'a' && i >= 5
+				if ok, _ := dst.Has(key); ok != want {
+					t.Errorf("%s: key %x: presence mismatch: have %v, want %v", name, key, ok, want)
+				}
+			}
+		}
+	}
+	// Resume positions outside of the exported prefixes must be rejected
+	if err := ExportDatabase(src, filepath.Join(dir, "bad"), prefixes, []byte("c")); err == nil {
+		t.Errorf("export resumed outside of its prefixes")
+	}
+}
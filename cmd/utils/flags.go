@@ -113,6 +113,14 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble', default = engine of the existing database, leveldb for new ones)",
+	}
+	AncientNoChecksumsFlag = cli.BoolFlag{
+		Name:  "datadir.ancient.nochecksums",
+		Usage: "Disable the item checksums of newly created ancient tables (existing tables keep their format)",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -187,7 +195,7 @@ var (
 	defaultSyncMode = odf.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "snap" or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -204,6 +212,11 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index by-hash for (default = index all blocks)",
 		Value: 0,
 	}
+	HistoryBodiesFlag = cli.Uint64Flag{
+		Name:  "history.bodies",
+		Usage: "Number of recent blocks to retain bodies and receipts for (default = 0, keep all blocks)",
+		Value: 0,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -308,6 +321,16 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool.pooljournal",
+		Usage: "Disk journal snapshotting all pooled transactions, remote ones included, to survive node restarts (disabled if empty)",
+		Value: core.DefaultTxPoolConfig.PoolJournal,
+	}
+	TxPoolPoolJournalSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.pooljournalslots",
+		Usage: "Maximum number of remote transaction slots stored in the pool journal",
+		Value: core.DefaultTxPoolConfig.PoolJournalSlots,
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
@@ -464,6 +487,59 @@ var (
 		Usage: "Sets a cap on transaction fee (in odfer) that can be sent via the RPC APIs (0 = no cap)",
 		Value: odf.DefaultConfig.RPCTxFeeCap,
 	}
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the JWT authenticated HTTP and WS-RPC server",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Authenticated RPC server listening interface",
+		Value: node.DefaultAuthHost,
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Authenticated RPC server listening port",
+		Value: node.DefaultAuthPort,
+	}
+	AuthRPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests on the authenticated RPC server (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+	}
+	AuthRPCApiFlag = cli.StringFlag{
+		Name:  "authrpc.api",
+		Usage: "API's offered only over the authenticated RPC server while it is enabled",
+		Value: strings.Join(node.DefaultConfig.AuthModules, ","),
+	}
+	AuthRPCJWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to a hex encoded 32 byte secret for authenticating RPC requests (default = inside the datadir)",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in a batch over HTTP and WS (0 = no limit)",
+		Value: node.DefaultConfig.BatchRequestLimit,
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.responselimit",
+		Usage: "Maximum number of result bytes in a response over HTTP and WS (0 = no limit)",
+		Value: node.DefaultConfig.BatchResponseMaxSize,
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit",
+		Usage: "Calls per second each remote host may make to a single modfod over HTTP and WS (0 = no limit)",
+		Value: node.DefaultConfig.RPCRateLimit,
+	}
+	RPCRateBurstFlag = cli.IntFlag{
+		Name:  "rpc.rateburst",
+		Usage: "Number of calls a remote host may burst above --rpc.ratelimit",
+		Value: node.DefaultConfig.RPCRateBurst,
+	}
+	RPCSlowCallFlag = cli.DurationFlag{
+		Name:  "rpc.slowcall",
+		Usage: "Log HTTP and WS RPC calls taking longer than this (0 = disabled)",
+		Value: node.DefaultConfig.RPCSlowCallThreshold,
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "odfstats",
@@ -486,6 +562,16 @@ var (
 		Name:  "ipcpath",
 		Usage: "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
 	}
+	IPCAllowFlag = cli.StringFlag{
+		Name:  "ipc.allow",
+		Usage: "Comma separated list of the only namespaces and modfods callable over the IPC-RPC interface",
+		Value: "",
+	}
+	IPCDenyFlag = cli.StringFlag{
+		Name:  "ipc.deny",
+		Usage: "Comma separated list of namespaces and modfods not callable over the IPC-RPC interface",
+		Value: "",
+	}
 	HTTPEnabledFlag = cli.BoolFlag{
 		Name:  "http",
 		Usage: "Enable the HTTP-RPC server",
@@ -515,6 +601,16 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	HTTPAllowFlag = cli.StringFlag{
+		Name:  "http.allow",
+		Usage: "Comma separated list of the only namespaces and modfods callable over the HTTP-RPC interface",
+		Value: "",
+	}
+	HTTPDenyFlag = cli.StringFlag{
+		Name:  "http.deny",
+		Usage: "Comma separated list of namespaces and modfods not callable over the HTTP-RPC interface",
+		Value: "",
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -548,6 +644,16 @@ var (
 		Usage: "API's offered over the WS-RPC interface",
 		Value: "",
 	}
+	WSAllowFlag = cli.StringFlag{
+		Name:  "ws.allow",
+		Usage: "Comma separated list of the only namespaces and modfods callable over the WS-RPC interface",
+		Value: "",
+	}
+	WSDenyFlag = cli.StringFlag{
+		Name:  "ws.deny",
+		Usage: "Comma separated list of namespaces and modfods not callable over the WS-RPC interface",
+		Value: "",
+	}
 	WSAllowedOriginsFlag = cli.StringFlag{
 		Name:  "ws.origins",
 		Usage: "Origins from which to accept websockets requests",
@@ -938,6 +1044,12 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = SplitAndTrim(ctx.GlobalString(HTTPVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(HTTPAllowFlag.Name) {
+		cfg.HTTPAccess.Allow = SplitAndTrim(ctx.GlobalString(HTTPAllowFlag.Name))
+	}
+	if ctx.GlobalIsSet(HTTPDenyFlag.Name) {
+		cfg.HTTPAccess.Deny = SplitAndTrim(ctx.GlobalString(HTTPDenyFlag.Name))
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -951,6 +1063,49 @@ func setGraphQL(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setAuthRPC configures the JWT authenticated RPC server from the command line
+// flags, leaving it disabled unless requested.
+func setAuthRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(AuthRPCEnabledFlag.Name) && cfg.AuthAddr == "" {
+		cfg.AuthAddr = "127.0.0.1"
+		if ctx.GlobalIsSet(AuthRPCListenAddrFlag.Name) {
+			cfg.AuthAddr = ctx.GlobalString(AuthRPCListenAddrFlag.Name)
+		}
+	}
+	if ctx.GlobalIsSet(AuthRPCPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthRPCPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthRPCVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = SplitAndTrim(ctx.GlobalString(AuthRPCVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCApiFlag.Name) {
+		cfg.AuthModules = SplitAndTrim(ctx.GlobalString(AuthRPCApiFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(AuthRPCJWTSecretFlag.Name)
+	}
+}
+
+// setRPCLimits applies the resource limits of the HTTP and WebSocket RPC endpoints
+// from the command line flags.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		cfg.BatchRequestLimit = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		cfg.BatchResponseMaxSize = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimit = ctx.GlobalFloat64(RPCRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateBurstFlag.Name) {
+		cfg.RPCRateBurst = ctx.GlobalInt(RPCRateBurstFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCSlowCallFlag.Name) {
+		cfg.RPCSlowCallThreshold = ctx.GlobalDuration(RPCSlowCallFlag.Name)
+	}
+}
+
 // setWS creates the WebSocket RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func setWS(ctx *cli.Context, cfg *node.Config) {
@@ -987,6 +1142,12 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSApiFlag.Name) {
 		cfg.WSModules = SplitAndTrim(ctx.GlobalString(WSApiFlag.Name))
 	}
+	if ctx.GlobalIsSet(WSAllowFlag.Name) {
+		cfg.WSAccess.Allow = SplitAndTrim(ctx.GlobalString(WSAllowFlag.Name))
+	}
+	if ctx.GlobalIsSet(WSDenyFlag.Name) {
+		cfg.WSAccess.Deny = SplitAndTrim(ctx.GlobalString(WSDenyFlag.Name))
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -999,6 +1160,12 @@ func setIPC(ctx *cli.Context, cfg *node.Config) {
 	case ctx.GlobalIsSet(IPCPathFlag.Name):
 		cfg.IPCPath = ctx.GlobalString(IPCPathFlag.Name)
 	}
+	if ctx.GlobalIsSet(IPCAllowFlag.Name) {
+		cfg.IPCAccess.Allow = SplitAndTrim(ctx.GlobalString(IPCAllowFlag.Name))
+	}
+	if ctx.GlobalIsSet(IPCDenyFlag.Name) {
+		cfg.IPCAccess.Deny = SplitAndTrim(ctx.GlobalString(IPCDenyFlag.Name))
+	}
 }
 
 // setLes configures the les server and ultra light client settings from the command line flags.
@@ -1207,6 +1374,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setHTTP(ctx, cfg)
 	setGraphQL(ctx, cfg)
 	setWS(ctx, cfg)
+	setAuthRPC(ctx, cfg)
+	setRPCLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
@@ -1215,6 +1384,16 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		engine := ctx.GlobalString(DBEngineFlag.Name)
+		if engine != rawdb.DBLeveldb && engine != rawdb.DBPebble {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", engine)
+		}
+		cfg.DBEngine = engine
+	}
+	if ctx.GlobalIsSet(AncientNoChecksumsFlag.Name) {
+		cfg.NoFreezerChecksums = ctx.GlobalBool(AncientNoChecksumsFlag.Name)
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -1320,6 +1499,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPoolJournalFlag.Name) {
+		cfg.PoolJournal = ctx.GlobalString(TxPoolPoolJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPoolJournalSlotsFlag.Name) {
+		cfg.PoolJournalSlots = ctx.GlobalUint64(TxPoolPoolJournalSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1529,6 +1714,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *odf.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(HistoryBodiesFlag.Name) {
+		cfg.BodyHistory = ctx.GlobalUint64(HistoryBodiesFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
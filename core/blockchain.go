@@ -129,6 +129,7 @@ type CacheConfig struct {
 	TrieDirtyDisabled   bool          // Whodfer to disable trie write caching and GC altogodfer (archive node)
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
+	BodyHistory         uint64        // Number of recent blocks to retain bodies and receipts for (0 = keep all)
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -172,6 +173,7 @@ type BlockChain struct {
 	//  * N:   means N block limit [HEAD-N+1, HEAD] and delete extra indexes
 	//  * nil: disable tx reindexer/deleter, but still index new blocks
 	txLookupLimit uint64
+	historyTail   uint64 // Tail the body pruner is discarding history up to (atomic)
 
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
@@ -349,6 +351,11 @@ func NewBlockChain(db odfdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		bc.wg.Add(1)
 		go bc.maintainTxIndex(txIndexBlock)
 	}
+	// If ancient body pruning is requested, start the background pruner
+	if bc.cacheConfig.BodyHistory > 0 {
+		bc.wg.Add(1)
+		go bc.maintainBodyHistory()
+	}
 	// If periodic cache journal is required, spin it up.
 	if bc.cacheConfig.TrieCleanRejournal > 0 {
 		if bc.cacheConfig.TrieCleanRejournal < time.Minute {
@@ -458,6 +465,9 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		// chain reparation mechanism without deleting any data!
 		if currentBlock := bc.CurrentBlock(); currentBlock != nil && header.Number.Uint64() <= currentBlock.NumberU64() {
 			newHeadBlock := bc.GetBlock(header.Hash(), header.Number.Uint64())
+			if newHeadBlock != nil && bc.bodyPruned(newHeadBlock.NumberU64()) {
+				newHeadBlock = nil // Body pruned from disk, only the cache remembers it
+			}
 			if newHeadBlock == nil {
 				log.Error("Gap in the chain, rewinding to genesis", "number", header.Number, "hash", header.Hash())
 				newHeadBlock = bc.genesisBlock
@@ -491,7 +501,7 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		if currentFastBlock := bc.CurrentFastBlock(); currentFastBlock != nil && header.Number.Uint64() < currentFastBlock.NumberU64() {
 			newHeadFastBlock := bc.GetBlock(header.Hash(), header.Number.Uint64())
 			// If either blocks reached nil, reset to the genesis state
-			if newHeadFastBlock == nil {
+			if newHeadFastBlock == nil || bc.bodyPruned(newHeadFastBlock.NumberU64()) {
 				newHeadFastBlock = bc.genesisBlock
 			}
 			rawdb.WriteHeadFastBlockHash(db, newHeadFastBlock.Hash())
@@ -547,6 +557,11 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		log.Warn("Rewinding blockchain", "target", head)
 		bc.hc.SetHead(head, updateFn, delFn)
 	}
+	// Rewinding below the pruned history moves the ancient tail back, let the
+	// transaction indexer cover the re-imported blocks again
+	if tail, err := bc.db.Tail(); err == nil && tail < atomic.LoadUint64(&bc.historyTail) {
+		atomic.StoreUint64(&bc.historyTail, tail)
+	}
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -2239,42 +2254,56 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	// need to reindex all necessary transactions before starting to process any
 	// pruning requests.
 	if ancients > 0 {
-		var from = uint64(0)
-		if bc.txLookupLimit != 0 && ancients > bc.txLookupLimit {
+		var from = bc.indexFloor()
+		if bc.txLookupLimit != 0 && ancients > bc.txLookupLimit && ancients-bc.txLookupLimit > from {
 			from = ancients - bc.txLookupLimit
 		}
-		rawdb.IndexTransactions(bc.db, from, ancients, bc.quit)
+		if from < ancients {
+			rawdb.IndexTransactions(bc.db, from, ancients, bc.quit)
+		}
 	}
 	// indexBlocks reindexes or unindexes transactions depending on user configuration
 	indexBlocks := func(tail *uint64, head uint64, done chan struct{}) {
 		defer func() { done <- struct{}{} }()
 
+		// Bodies below the pruned history can't be (un)indexed any more, the
+		// body pruner removes their lookups itself
+		floor := bc.indexFloor()
+
 		// If the user just upgraded Godf to a new version which supports transaction
 		// index pruning, write the new tail and remove anything older.
 		if tail == nil {
-			if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
+			if bc.txLookupLimit == 0 || head < bc.txLookupLimit || head-bc.txLookupLimit+1 <= floor {
 				// Nothing to delete, write the tail and return
-				rawdb.WriteTxIndexTail(bc.db, 0)
+				rawdb.WriteTxIndexTail(bc.db, floor)
 			} else {
 				// Prune all stale tx indices and record the tx index tail
-				rawdb.UnindexTransactions(bc.db, 0, head-bc.txLookupLimit+1, bc.quit)
+				rawdb.UnindexTransactions(bc.db, floor, head-bc.txLookupLimit+1, bc.quit)
 			}
 			return
 		}
 		// If a previous indexing existed, make sure that we fill in any missing entries
 		if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
-			if *tail > 0 {
-				rawdb.IndexTransactions(bc.db, 0, *tail, bc.quit)
+			if *tail > floor {
+				rawdb.IndexTransactions(bc.db, floor, *tail, bc.quit)
 			}
 			return
 		}
 		// Update the transaction index to the new chain state
-		if head-bc.txLookupLimit+1 < *tail {
+		from := head - bc.txLookupLimit + 1
+		if from < floor {
+			from = floor
+		}
+		if from < *tail {
 			// Reindex a part of missing indices and rewind index tail to HEAD-limit
-			rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, bc.quit)
+			rawdb.IndexTransactions(bc.db, from, *tail, bc.quit)
 		} else {
 			// Unindex a part of stale indices and forward index tail to HEAD-limit
-			rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, bc.quit)
+			start := *tail
+			if start < floor {
+				start = floor
+			}
+			rawdb.UnindexTransactions(bc.db, start, from, bc.quit)
 		}
 	}
 	// Any reindexing done, start listening to chain events and moving the index window
@@ -2307,6 +2336,87 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	}
 }
 
+// maintainBodyHistory is responsible for periodically discarding the block
+// bodies and receipts from the ancient store which fall outside of the
+// configured retention window.
+//
+// Only frozen data is pruned: blocks still in the active key-value store are
+// kept around until they migrate into the freezer and get dropped from there.
+func (bc *BlockChain) maintainBodyHistory() {
+	defer bc.wg.Done()
+
+	bc.pruneBodyHistory(bc.CurrentBlock().NumberU64())
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			bc.pruneBodyHistory(bc.CurrentBlock().NumberU64())
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// pruneBodyHistory discards the frozen block bodies and receipts falling outside
+// of the retention window relative to the given head, together with the lookup
+// entries of their transactions.
+func (bc *BlockChain) pruneBodyHistory(head uint64) {
+	if head <= bc.cacheConfig.BodyHistory {
+		return
+	}
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return // Freezer not available (e.g. memory database)
+	}
+	tail := head - bc.cacheConfig.BodyHistory
+	if tail > frozen {
+		tail = frozen
+	}
+	old, err := bc.db.Tail()
+	if err != nil || old >= tail {
+		return
+	}
+	// Stop the transaction indexer from touching the range, and drop the
+	// lookups of the discarded blocks while their bodies are still around
+	atomic.StoreUint64(&bc.historyTail, tail)
+
+	if indexed := rawdb.ReadTxIndexTail(bc.db); indexed == nil || *indexed < tail {
+		from := old
+		if indexed != nil && *indexed > from {
+			from = *indexed
+		}
+		rawdb.UnindexTransactions(bc.db, from, tail, bc.quit)
+
+		if indexed := rawdb.ReadTxIndexTail(bc.db); indexed == nil || *indexed < tail {
+			return // Interrupted, retry on the next round
+		}
+	}
+	if err := bc.db.TruncateTail(tail); err != nil {
+		log.Error("Failed to prune ancient block history", "tail", tail, "err", err)
+		return
+	}
+	log.Info("Pruned ancient block history", "from", old, "tail", tail)
+}
+
+// indexFloor returns the first block whose transactions may be indexed, all the
+// older bodies are discarded or being discarded by the body pruner.
+func (bc *BlockChain) indexFloor() uint64 {
+	floor := atomic.LoadUint64(&bc.historyTail)
+	if tail, err := bc.db.Tail(); err == nil && tail > floor {
+		floor = tail
+	}
+	return floor
+}
+
+// bodyPruned reports whether the body of the given block was already discarded
+// from the ancient store by the body pruner.
+func (bc *BlockChain) bodyPruned(number uint64) bool {
+	tail, err := bc.db.Tail()
+	return err == nil && number < tail
+}
+
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() []*types.Block {
 	blocks := make([]*types.Block, 0, bc.badBlocks.Len())
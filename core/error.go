@@ -16,7 +16,13 @@
 
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/odfdb"
+)
 
 var (
 	// ErrKnownBlock is returned when a block to import is already known locally.
@@ -63,4 +69,50 @@ var (
 	// ErrIntrinsicGas is returned if the transaction is specified to use less gas
 	// than required to start the invocation.
 	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+	// ErrTipAboveFeeCap is a sanity error to ensure no one is able to specify a
+	// transaction with a tip higher than the total fee cap.
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+
+	// ErrTipVeryHigh is a sanity error to avoid extremely big numbers specified
+	// in the tip field.
+	ErrTipVeryHigh = errors.New("max priority fee per gas higher than 2^256-1")
+
+	// ErrFeeCapVeryHigh is a sanity error to avoid extremely big numbers specified
+	// in the fee cap field.
+	ErrFeeCapVeryHigh = errors.New("max fee per gas higher than 2^256-1")
+
+	// ErrFeeCapTooLow is returned if the transaction fee cap is less than the
+	// the base fee of the block.
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
+
+	// ErrTxTypeNotSupported is returned if a transaction is not supported in the
+	// current network configuration.
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
 )
+
+// PrunedHistoryError is returned when the requested data belongs to a block below
+// the tail of the ancient store, so its body and receipts have been discarded by
+// the history pruner.
+type PrunedHistoryError struct {
+	Tail uint64 // First block whose body and receipts are still available
+}
+
+func (e *PrunedHistoryError) Error() string {
+	return fmt.Sprintf("pruned history unavailable: data below block %d has been discarded", e.Tail)
+}
+
+// ErrorCode returns the JSON error code for pruned history.
+func (e *PrunedHistoryError) ErrorCode() int {
+	return 4444
+}
+
+// CheckPrunedHistory returns a PrunedHistoryError if the block with the given
+// number lies below the pruned tail of the ancient store, or nil otherwise.
+func CheckPrunedHistory(db odfdb.AncientReader, number uint64) error {
+	tail, err := db.Tail()
+	if err != nil || number >= tail {
+		return nil // Pruning unsupported or block still retained
+	}
+	return &PrunedHistoryError{Tail: tail}
+}
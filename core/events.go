@@ -24,6 +24,15 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// DropTxsEvent is posted when a batch of transactions is removed from the
+// transaction pool for the same reason. Transactions removed because their nonce
+// was used up by the chain, whodfer included or not, are not announced.
+type DropTxsEvent struct {
+	Txs         []*types.Transaction
+	Reason      TxDropReason
+	Replacement *types.Transaction // The replacing transaction, if Reason is TxDropReplaced
+}
+
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
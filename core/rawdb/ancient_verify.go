@@ -0,0 +1,187 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/log"
+	"github.com/odf/go-odf/odfdb"
+	"github.com/odf/go-odf/rlp"
+)
+
+// AncientCorruption describes a range of consecutive items within a table of the
+// ancient store which failed verification.
+type AncientCorruption struct {
+	Table string // Name of the freezer table holding the corrupted items
+	From  uint64 // Number of the first corrupted item
+	To    uint64 // Number of the last corrupted item (inclusive)
+	Err   error  // Verification failure of the first item in the range
+}
+
+// VerifyAncients checks the integrity of the ancient store, starting at the given
+// block number. Every item is retrieved, validating the stored checksums for the
+// tables having them, and is cross-checked against its block header:
+//
+//   - the canonical hashes against the header hashes
+//   - the total difficulties against the header difficulties
+//   - the bodies against the transaction and uncle roots
+//   - the receipts against the receipt root
+//
+// The hasher is used to derive the list roots, so it needs to be a trie (e.g. a
+// stack trie) for the checks to be meaningful. Bodies and receipts pruned from
+// the tail of the store are not checked.
+//
+// The returned corruptions are ordered by their first item number.
+func VerifyAncients(db odfdb.AncientReader, hasher types.Hasher, start uint64) ([]*AncientCorruption, error) {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return nil, err
+	}
+	tail, err := db.Tail()
+	if err != nil {
+		tail = 0 // Tail pruning unsupported, everything is retained
+	}
+	var (
+		corruptions []*AncientCorruption
+		ranges      = make(map[string]*AncientCorruption)
+
+		begin  = time.Now()
+		logged = time.Now()
+	)
+	// fail records a verification failure, extending the previous corrupted range
+	// of the table if the item follows right after it
+	fail := func(table string, number uint64, err error) {
+		if last := ranges[table]; last != nil && last.To+1 == number {
+			last.To = number
+			return
+		}
+		ranges[table] = &AncientCorruption{Table: table, From: number, To: number, Err: err}
+		corruptions = append(corruptions, ranges[table])
+	}
+	// Retrieve the parent total difficulty if we're not starting at genesis
+	var ptd *big.Int
+	if start > 0 && start <= frozen {
+		if blob, err := db.Ancient(freezerDifficultyTable, start-1); err == nil {
+			ptd = new(big.Int)
+			if err := rlp.DecodeBytes(blob, ptd); err != nil {
+				ptd = nil
+			}
+		}
+	}
+	for number := start; number < frozen; number++ {
+		// Verify the canonical hash and the header, which everything else is
+		// checked against
+		hash, err := db.Ancient(freezerHashTable, number)
+		if err != nil {
+			fail(freezerHashTable, number, err)
+		} else if len(hash) != common.HashLength {
+			fail(freezerHashTable, number, fmt.Errorf("invalid hash length %d", len(hash)))
+			hash = nil
+		}
+		header := new(types.Header)
+		if blob, err := db.Ancient(freezerHeaderTable, number); err != nil {
+			fail(freezerHeaderTable, number, err)
+			header = nil
+		} else if err := rlp.DecodeBytes(blob, header); err != nil {
+			fail(freezerHeaderTable, number, err)
+			header = nil
+		} else if header.Number == nil || header.Number.Uint64() != number {
+			fail(freezerHeaderTable, number, fmt.Errorf("number mismatch: have %v", header.Number))
+			header = nil
+		} else if len(hash) == common.HashLength && header.Hash() != common.BytesToHash(hash) {
+			fail(freezerHashTable, number, fmt.Errorf("hash mismatch: have %x, want %x", hash, header.Hash()))
+		}
+		// Verify the total difficulty, continuing with the expected one on failure
+		// to avoid flagging all subsequent items
+		td := new(big.Int)
+		if blob, err := db.Ancient(freezerDifficultyTable, number); err != nil {
+			fail(freezerDifficultyTable, number, err)
+			td = nil
+		} else if err := rlp.DecodeBytes(blob, td); err != nil {
+			fail(freezerDifficultyTable, number, err)
+			td = nil
+		}
+		if header != nil {
+			want := new(big.Int).Set(header.Difficulty)
+			if number > 0 {
+				if ptd != nil {
+					want.Add(want, ptd)
+				} else {
+					want = nil
+				}
+			}
+			if td != nil && want != nil && td.Cmp(want) != 0 {
+				fail(freezerDifficultyTable, number, fmt.Errorf("total difficulty mismatch: have %v, want %v", td, want))
+			}
+			if want != nil {
+				td = want
+			}
+		}
+		ptd = td
+
+		// Verify the body and receipts, unless they've been pruned
+		if number >= tail {
+			body := new(types.Body)
+			if blob, err := db.Ancient(freezerBodiesTable, number); err != nil {
+				fail(freezerBodiesTable, number, err)
+				body = nil
+			} else if err := rlp.DecodeBytes(blob, body); err != nil {
+				fail(freezerBodiesTable, number, err)
+				body = nil
+			} else if header != nil {
+				if root := types.DeriveSha(types.Transactions(body.Transactions), hasher); root != header.TxHash {
+					fail(freezerBodiesTable, number, fmt.Errorf("transaction root mismatch: have %x, want %x", root, header.TxHash))
+					body = nil
+				} else if root := types.CalcUncleHash(body.Uncles); root != header.UncleHash {
+					fail(freezerBodiesTable, number, fmt.Errorf("uncle root mismatch: have %x, want %x", root, header.UncleHash))
+					body = nil
+				}
+			}
+			var stored []*types.ReceiptForStorage
+			if blob, err := db.Ancient(freezerReceiptTable, number); err != nil {
+				fail(freezerReceiptTable, number, err)
+			} else if err := rlp.DecodeBytes(blob, &stored); err != nil {
+				fail(freezerReceiptTable, number, err)
+			} else if header != nil && body != nil {
+				// The receipt types are not stored, derive them from the transactions
+				if len(stored) != len(body.Transactions) {
+					fail(freezerReceiptTable, number, fmt.Errorf("receipt count mismatch: have %d, want %d", len(stored), len(body.Transactions)))
+				} else {
+					receipts := make(types.Receipts, len(stored))
+					for i, receipt := range stored {
+						receipts[i] = (*types.Receipt)(receipt)
+						receipts[i].Type = body.Transactions[i].Type()
+					}
+					if root := types.DeriveSha(receipts, hasher); root != header.ReceiptHash {
+						fail(freezerReceiptTable, number, fmt.Errorf("receipt root mismatch: have %x, want %x", root, header.ReceiptHash))
+					}
+				}
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying ancient store", "number", number, "frozen", frozen, "corruptions", len(corruptions), "elapsed", common.PrettyDuration(time.Since(begin)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Verified ancient store", "from", start, "frozen", frozen, "corruptions", len(corruptions), "elapsed", common.PrettyDuration(time.Since(begin)))
+	return corruptions, nil
+}
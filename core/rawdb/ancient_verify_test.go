@@ -0,0 +1,98 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/types"
+)
+
+// Tests that the ancient store verification accepts a consistent chain and
+// reports the items not matching their headers.
+func TestVerifyAncients(t *testing.T) {
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", true)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	// Freeze a few consistent blocks, each with a single transaction
+	var (
+		parent = common.Hash{}
+		td     = new(big.Int)
+	)
+	makeBlock := func(number uint64) (*types.Block, types.Receipts) {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(100),
+			Extra:      []byte("test block"),
+		}
+		txs := []*types.Transaction{types.NewTransaction(number, common.HexToAddress("0x1"), big.NewInt(1), 21000, big.NewInt(1), nil)}
+		receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{}}}
+
+		block := types.NewBlock(header, txs, nil, receipts, newHasher())
+		parent = block.Hash()
+		td = new(big.Int).Add(td, block.Difficulty())
+		return block, receipts
+	}
+	for i := uint64(0); i < 4; i++ {
+		block, receipts := makeBlock(i)
+		WriteAncientBlock(db, block, receipts, td)
+	}
+	if corruptions, err := VerifyAncients(db, newHasher(), 0); err != nil {
+		t.Fatalf("failed to verify ancients: %v", err)
+	} else if len(corruptions) != 0 {
+		t.Fatalf("unexpected corruptions in consistent chain: %v", corruptions[0].Err)
+	}
+	// Freeze blocks with mismatching receipts and total difficulties
+	for i := uint64(4); i < 7; i++ {
+		block, receipts := makeBlock(i)
+		receipts = types.Receipts{{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 21000, Logs: []*types.Log{}}}
+		WriteAncientBlock(db, block, receipts, td)
+	}
+	block, receipts := makeBlock(7)
+	WriteAncientBlock(db, block, receipts, new(big.Int).Add(td, common.Big1))
+
+	corruptions, err := VerifyAncients(db, newHasher(), 2)
+	if err != nil {
+		t.Fatalf("failed to verify ancients: %v", err)
+	}
+	want := []AncientCorruption{
+		{Table: freezerReceiptTable, From: 4, To: 6},
+		{Table: freezerDifficultyTable, From: 7, To: 7},
+	}
+	if len(corruptions) != len(want) {
+		t.Fatalf("corruption count mismatch: have %d, want %d", len(corruptions), len(want))
+	}
+	for i, c := range corruptions {
+		if c.Table != want[i].Table || c.From != want[i].From || c.To != want[i].To {
+			t.Errorf("corruption %d: have %s [%d-%d], want %s [%d-%d]", i, c.Table, c.From, c.To, want[i].Table, want[i].From, want[i].To)
+		}
+	}
+}
@@ -18,16 +18,26 @@ package rawdb
 
 import (
 	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync/atomic"
 	"time"
 
 	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/odfdb/leveldb"
 	"github.com/odf/go-odf/odfdb/memorydb"
+	"github.com/odf/go-odf/odfdb/pebble"
 	"github.com/odf/go-odf/log"
 	"github.com/olekukonko/tablewriter"
 )
@@ -95,6 +105,11 @@ func (db *nofreezedb) AncientSize(kind string) (uint64, error) {
 	return 0, errNotSupported
 }
 
+// Tail returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) Tail() (uint64, error) {
+	return 0, errNotSupported
+}
+
 // AppendAncient returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
 	return errNotSupported
@@ -105,6 +120,11 @@ func (db *nofreezedb) TruncateAncients(items uint64) error {
 	return errNotSupported
 }
 
+// TruncateTail returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) TruncateTail(tail uint64) error {
+	return errNotSupported
+}
+
 // Sync returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) Sync() error {
 	return errNotSupported
@@ -120,10 +140,11 @@ func NewDatabase(db odfdb.KeyValueStore) odfdb.Database {
 
 // NewDatabaseWithFreezer creates a high level database on top of a given key-
 // value data store with a freezer moving immutable chain segments into cold
-// storage.
-func NewDatabaseWithFreezer(db odfdb.KeyValueStore, freezer string, namespace string) (odfdb.Database, error) {
+// storage. The checksums flag enables item checksums in newly created freezer
+// tables.
+func NewDatabaseWithFreezer(db odfdb.KeyValueStore, freezer string, namespace string, checksums bool) (odfdb.Database, error) {
 	// Create the idle freezer instance
-	frdb, err := newFreezer(freezer, namespace)
+	frdb, err := newFreezer(freezer, namespace, checksums)
 	if err != nil {
 		return nil, err
 	}
@@ -213,24 +234,88 @@ func NewMemoryDatabaseWithCap(size int) odfdb.Database {
 	return NewDatabase(memorydb.NewWithCap(size))
 }
 
-// NewLevelDBDatabase creates a persistent key-value database without a freezer
-// moving immutable chain segments into cold storage.
-func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (odfdb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace)
+const (
+	// DBLeveldb is the name of the LevelDB key-value engine.
+	DBLeveldb = "leveldb"
+
+	// DBPebble is the name of the Pebble key-value engine.
+	DBPebble = "pebble"
+)
+
+// PreexistingDatabase checks the given data directory whodfer a database is
+// already instantiated at that location, and if so, returns the name of the
+// engine it was created with. An empty string is returned if there is none.
+func PreexistingDatabase(path string) string {
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
+		return "" // No pre-existing db
+	}
+	// Pebble keeps its options in a separate file, LevelDB never creates one
+	if matches, err := filepath.Glob(filepath.Join(path, "OPTIONS*")); err == nil && len(matches) > 0 {
+		return DBPebble
+	}
+	return DBLeveldb
+}
+
+// openKeyValueStore opens a persistent key-value store with the requested engine.
+// An empty engine selects the engine of the pre-existing database at the given
+// location, or LevelDB if there is none. Opening a database with a different
+// engine than it was created with is refused.
+func openKeyValueStore(engine string, file string, cache int, handles int, namespace string) (odfdb.KeyValueStore, error) {
+	existing := PreexistingDatabase(file)
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = DBLeveldb
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s was created with engine %q, cannot open with %q", file, existing, engine)
+	}
+	var (
+		kvdb odfdb.KeyValueStore
+		err  error
+	)
+	switch engine {
+	case DBLeveldb:
+		kvdb, err = leveldb.New(file, cache, handles, namespace)
+	case DBPebble:
+		kvdb, err = pebble.New(file, cache, handles, namespace)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Cross check the engine recorded in the metadata, stamp fresh databases
+	if stored := ReadDatabaseEngine(kvdb); stored == "" {
+		WriteDatabaseEngine(kvdb, engine)
+	} else if stored != engine {
+		kvdb.Close()
+		return nil, fmt.Errorf("database %s was created with engine %q, cannot open with %q", file, stored, engine)
+	}
+	return kvdb, nil
+}
+
+// NewDiskDatabase creates a persistent key-value database using the given
+// engine without a freezer moving immutable chain segments into cold storage.
+// An empty engine reuses the one of the pre-existing database, if any.
+func NewDiskDatabase(engine string, file string, cache int, handles int, namespace string) (odfdb.Database, error) {
+	db, err := openKeyValueStore(engine, file, cache, handles, namespace)
 	if err != nil {
 		return nil, err
 	}
 	return NewDatabase(db), nil
 }
 
-// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
-// freezer moving immutable chain segments into cold storage.
-func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (odfdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace)
+// NewDiskDatabaseWithFreezer creates a persistent key-value database using the
+// given engine with a freezer moving immutable chain segments into cold storage.
+// An empty engine reuses the one of the pre-existing database, if any.
+func NewDiskDatabaseWithFreezer(engine string, file string, cache int, handles int, freezer string, namespace string, checksums bool) (odfdb.Database, error) {
+	kvdb, err := openKeyValueStore(engine, file, cache, handles, namespace)
 	if err != nil {
 		return nil, err
 	}
-	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace)
+	frdb, err := NewDatabaseWithFreezer(kvdb, freezer, namespace, checksums)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -238,6 +323,30 @@ func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer
 	return frdb, nil
 }
 
+// NewLevelDBDatabase creates a persistent key-value database without a freezer
+// moving immutable chain segments into cold storage.
+func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (odfdb.Database, error) {
+	return NewDiskDatabase(DBLeveldb, file, cache, handles, namespace)
+}
+
+// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
+// freezer moving immutable chain segments into cold storage.
+func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (odfdb.Database, error) {
+	return NewDiskDatabaseWithFreezer(DBLeveldb, file, cache, handles, freezer, namespace, true)
+}
+
+// NewPebbleDBDatabase creates a persistent key-value database backed by Pebble
+// without a freezer moving immutable chain segments into cold storage.
+func NewPebbleDBDatabase(file string, cache int, handles int, namespace string) (odfdb.Database, error) {
+	return NewDiskDatabase(DBPebble, file, cache, handles, namespace)
+}
+
+// NewPebbleDBDatabaseWithFreezer creates a persistent key-value database backed
+// by Pebble with a freezer moving immutable chain segments into cold storage.
+func NewPebbleDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (odfdb.Database, error) {
+	return NewDiskDatabaseWithFreezer(DBPebble, file, cache, handles, freezer, namespace, true)
+}
+
 type counter uint64
 
 func (c counter) String() string {
@@ -248,10 +357,20 @@ func (c counter) Percentage(current uint64) string {
 	return fmt.Sprintf("%d", current*100/uint64(c))
 }
 
+// inspectBuckets is the number of power-of-two size buckets in the entry size
+// histograms, covering entries up to 4GB.
+const inspectBuckets = 32
+
+// inspectWindows is the number of equally sized strata the key space is split
+// into when sampling, each of them contributing one randomly placed window.
+const inspectWindows = 1024
+
 // stat stores sizes and count for a parameter
 type stat struct {
 	size  common.StorageSize
 	count counter
+	hist  [inspectBuckets]uint64 // Number of entries per power-of-two size bucket
+	top   largestKeys            // Largest entries seen, if tracked
 }
 
 // Add size to the stat and increase the counter by 1
@@ -260,6 +379,29 @@ func (s *stat) Add(size common.StorageSize) {
 	s.count++
 }
 
+// track records an entry in the size histogram and, if n is non-zero, in the
+// list of the n largest entries.
+func (s *stat) track(key []byte, size uint64, n int) {
+	bucket := bits.Len64(size)
+	if bucket > 0 {
+		bucket-- // Sizes in [2^i, 2^(i+1)) go into bucket i
+	}
+	if bucket >= inspectBuckets {
+		bucket = inspectBuckets - 1
+	}
+	s.hist[bucket]++
+
+	if n == 0 {
+		return
+	}
+	if len(s.top) < n {
+		heap.Push(&s.top, InspectKey{Key: common.CopyBytes(key), Size: size})
+	} else if size > s.top[0].Size {
+		s.top[0] = InspectKey{Key: common.CopyBytes(key), Size: size}
+		heap.Fix(&s.top, 0)
+	}
+}
+
 func (s *stat) Size() string {
 	return s.size.String()
 }
@@ -268,157 +410,382 @@ func (s *stat) Count() string {
 	return s.count.String()
 }
 
-// InspectDatabase traverses the entire database and checks the size
-// of all different categories of data.
-func InspectDatabase(db odfdb.Database) error {
-	it := db.NewIterator(nil, nil)
-	defer it.Release()
+// largestKeys is a min-heap of database entries ordered by size, used to keep
+// track of the largest entries of a category.
+type largestKeys []InspectKey
+
+func (h largestKeys) Len() int            { return len(h) }
+func (h largestKeys) Less(i, j int) bool  { return h[i].Size < h[j].Size }
+func (h largestKeys) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *largestKeys) Push(x interface{}) { *h = append(*h, x.(InspectKey)) }
+
+func (h *largestKeys) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// InspectConfig contains the options of a database inspection.
+type InspectConfig struct {
+	Prefixes  [][]byte // Key prefixes to restrict the inspection to (nil = whole database)
+	Top       int      // Number of largest entries to report per category
+	Sample    float64  // Fraction of the key space to scan for estimates (0 = full scan)
+	Histogram bool     // Whodfer to print the size histograms in table mode
+	JSON      bool     // Whodfer to print the report as JSON instead of tables
+}
+
+// InspectKey is a single database entry reported by an inspection.
+type InspectKey struct {
+	Key  hexutil.Bytes `json:"key"`
+	Size uint64        `json:"size"`
+}
+
+// InspectStat contains the inspection results of a data category.
+type InspectStat struct {
+	Database string `json:"database"`
+	Category string `json:"category"`
+	Size     uint64 `json:"size"`
+	Count    uint64 `json:"count"`
+
+	// Histogram contains the number of entries per size bucket, where bucket i
+	// holds the entries of [2^i, 2^(i+1)) bytes. Trailing empty buckets are
+	// omitted. Not available for the ancient store.
+	Histogram []uint64 `json:"histogram,omitempty"`
+
+	// Largest contains the largest entries seen, in descending size order. When
+	// sampling, only the scanned entries are considered.
+	Largest []InspectKey `json:"largest,omitempty"`
+}
+
+// InspectReport contains the results of a database inspection.
+type InspectReport struct {
+	Sample      float64        `json:"sample,omitempty"` // Fraction of the key space scanned, zero for full scans
+	Stats       []*InspectStat `json:"stats"`
+	Unaccounted *InspectStat   `json:"unaccounted"`
+	Total       uint64         `json:"total"`
+}
 
+// inspectCategories are the categories of the key-value store reported by an
+// inspection, in display order.
+var inspectCategories = [][2]string{
+	{"Key-Value store", "Headers"},
+	{"Key-Value store", "Bodies"},
+	{"Key-Value store", "Receipt lists"},
+	{"Key-Value store", "Difficulties"},
+	{"Key-Value store", "Block number->hash"},
+	{"Key-Value store", "Block hash->number"},
+	{"Key-Value store", "Transaction index"},
+	{"Key-Value store", "Bloombit index"},
+	{"Key-Value store", "Contract codes"},
+	{"Key-Value store", "Trie nodes"},
+	{"Key-Value store", "Trie preimages"},
+	{"Key-Value store", "Account snapshot"},
+	{"Key-Value store", "Storage snapshot"},
+	{"Key-Value store", "Clique snapshots"},
+	{"Key-Value store", "Singleton metadata"},
+	{"Light client", "CHT trie nodes"},
+	{"Light client", "Bloom trie nodes"},
+}
+
+// inspectCategory returns the name of the data category a database key belongs
+// to, or an empty string if it's unknown.
+func inspectCategory(key []byte) string {
+	switch {
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
+		return "Headers"
+	case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
+		return "Bodies"
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
+		return "Receipt lists"
+	case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
+		return "Difficulties"
+	case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
+		return "Block number->hash"
+	case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
+		return "Block hash->number"
+	case len(key) == common.HashLength:
+		return "Trie nodes"
+	case bytes.HasPrefix(key, codePrefix) && len(key) == len(codePrefix)+common.HashLength:
+		return "Contract codes"
+	case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
+		return "Transaction index"
+	case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
+		return "Account snapshot"
+	case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
+		return "Storage snapshot"
+	case bytes.HasPrefix(key, preimagePrefix) && len(key) == (len(preimagePrefix)+common.HashLength):
+		return "Trie preimages"
+	case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
+		return "Bloombit index"
+	case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
+		return "Clique snapshots"
+	case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
+		return "CHT trie nodes"
+	case bytes.HasPrefix(key, []byte("blt-")) && len(key) == 4+common.HashLength:
+		return "Bloom trie nodes"
+	default:
+		for _, meta := range [][]byte{databaseVerisionKey, databaseEngineKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey, snapshotSyncStatusKey} {
+			if bytes.Equal(key, meta) {
+				return "Singleton metadata"
+			}
+		}
+		return ""
+	}
+}
+
+// keyPosition maps a database key (without the inspected prefix) onto a 64 bit
+// position in the key space, used to place the sampling windows.
+func keyPosition(key []byte) uint64 {
+	var pos [8]byte
+	copy(pos[:], key)
+	return binary.BigEndian.Uint64(pos[:])
+}
+
+// CollectDatabaseStats traverses the database and gathers the counts and sizes
+// of all the different categories of data.
+//
+// If sampling is requested, the key space (after each inspected prefix) is split
+// into equally sized strata, and only a randomly placed window of each stratum
+// covering the requested fraction is scanned. Every entry is thus scanned with
+// the same probability and the totals are extrapolated from the scanned ones.
+// The estimates are accurate for large categories with uniformly distributed
+// keys (trie nodes, snapshots, codes), but may be far off for small ones whose
+// keys are clustered (e.g. headers keyed by block number).
+func CollectDatabaseStats(db odfdb.Database, config *InspectConfig) (*InspectReport, error) {
+	if config == nil {
+		config = new(InspectConfig)
+	}
+	if config.Sample < 0 || config.Sample > 1 {
+		return nil, fmt.Errorf("invalid sample fraction %v", config.Sample)
+	}
+	sampling := config.Sample > 0 && config.Sample < 1
+
+	prefixes := config.Prefixes
+	if len(prefixes) == 0 {
+		prefixes = [][]byte{nil}
+	}
 	var (
 		count  int64
 		start  = time.Now()
 		logged = time.Now()
 
-		// Key-value store statistics
-		headers         stat
-		bodies          stat
-		receipts        stat
-		tds             stat
-		numHashPairings stat
-		hashNumPairings stat
-		tries           stat
-		codes           stat
-		txLookups       stat
-		accountSnaps    stat
-		storageSnaps    stat
-		preimages       stat
-		bloomBits       stat
-		cliqueSnaps     stat
-
-		// Ancient store statistics
-		ancientHeadersSize  common.StorageSize
-		ancientBodiesSize   common.StorageSize
-		ancientReceiptsSize common.StorageSize
-		ancientTdsSize      common.StorageSize
-		ancientHashesSize   common.StorageSize
-
-		// Les statistic
-		chtTrieNodes   stat
-		bloomTrieNodes stat
-
-		// Meta- and unaccounted data
-		metadata    stat
+		stats       = make(map[string]*stat)
 		unaccounted stat
-
-		// Totals
-		total common.StorageSize
 	)
-	// Inspect key-value database first.
-	for it.Next() {
+	for _, category := range inspectCategories {
+		stats[category[1]] = new(stat)
+	}
+	// process accounts a single database entry into its category
+	process := func(key, value []byte) {
 		var (
-			key  = it.Key()
-			size = common.StorageSize(len(key) + len(it.Value()))
+			size = uint64(len(key) + len(value))
+			s    = &unaccounted
 		)
-		total += size
-		switch {
-		case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
-			headers.Add(size)
-		case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
-			bodies.Add(size)
-		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
-			receipts.Add(size)
-		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
-			tds.Add(size)
-		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
-			numHashPairings.Add(size)
-		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
-			hashNumPairings.Add(size)
-		case len(key) == common.HashLength:
-			tries.Add(size)
-		case bytes.HasPrefix(key, codePrefix) && len(key) == len(codePrefix)+common.HashLength:
-			codes.Add(size)
-		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
-			txLookups.Add(size)
-		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
-			accountSnaps.Add(size)
-		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
-			storageSnaps.Add(size)
-		case bytes.HasPrefix(key, preimagePrefix) && len(key) == (len(preimagePrefix)+common.HashLength):
-			preimages.Add(size)
-		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
-			bloomBits.Add(size)
-		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
-			cliqueSnaps.Add(size)
-		case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
-			chtTrieNodes.Add(size)
-		case bytes.HasPrefix(key, []byte("blt-")) && len(key) == 4+common.HashLength:
-			bloomTrieNodes.Add(size)
-		default:
-			var accounted bool
-			for _, meta := range [][]byte{databaseVerisionKey, headHeaderKey, headBlockKey, headFastBlockKey, fastTrieProgressKey} {
-				if bytes.Equal(key, meta) {
-					metadata.Add(size)
-					accounted = true
-					break
-				}
-			}
-			if !accounted {
-				unaccounted.Add(size)
-			}
+		if category := inspectCategory(key); category != "" {
+			s = stats[category]
 		}
+		s.Add(common.StorageSize(size))
+		s.track(key, size, config.Top)
+
 		count++
 		if count%1000 == 0 && time.Since(logged) > 8*time.Second {
 			log.Info("Inspecting database", "count", count, "elapsed", common.PrettyDuration(time.Since(start)))
 			logged = time.Now()
 		}
 	}
-	// Inspect append-only file store then.
-	ancientSizes := []*common.StorageSize{&ancientHeadersSize, &ancientBodiesSize, &ancientReceiptsSize, &ancientHashesSize, &ancientTdsSize}
-	for i, category := range []string{freezerHeaderTable, freezerBodiesTable, freezerReceiptTable, freezerHashTable, freezerDifficultyTable} {
-		if size, err := db.AncientSize(category); err == nil {
-			*ancientSizes[i] += common.StorageSize(size)
-			total += common.StorageSize(size)
+	// scan iterates the entries of a prefix, from the given key space position up
+	// to and including the last one
+	scan := func(prefix []byte, from, last uint64) error {
+		var begin []byte
+		if from > 0 {
+			begin = make([]byte, 8)
+			binary.BigEndian.PutUint64(begin, from)
+		}
+		it := db.NewIterator(prefix, begin)
+		defer it.Release()
+
+		for it.Next() {
+			if keyPosition(it.Key()[len(prefix):]) > last {
+				break
+			}
+			process(it.Key(), it.Value())
+		}
+		return it.Error()
+	}
+	// Inspect key-value database first.
+	for _, prefix := range prefixes {
+		if !sampling {
+			if err := scan(prefix, 0, math.MaxUint64); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		const stride = math.MaxUint64/inspectWindows + 1
+		width := uint64(config.Sample * float64(stride))
+		if width == 0 {
+			width = 1
+		}
+		for i := uint64(0); i < inspectWindows; i++ {
+			// Place the window randomly within the stratum, wrapping around its
+			// end so all positions are equally likely to be scanned
+			var (
+				base   = i * stride
+				offset = uint64(rand.Int63n(int64(stride)))
+			)
+			if offset+width <= stride {
+				if err := scan(prefix, base+offset, base+offset+width-1); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := scan(prefix, base+offset, base+stride-1); err != nil {
+				return nil, err
+			}
+			if err := scan(prefix, base, base+offset+width-stride-1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Assemble the report, extrapolating the sampled counts
+	report := &InspectReport{}
+	if sampling {
+		report.Sample = config.Sample
+	}
+	export := func(database, category string, s *stat) *InspectStat {
+		result := &InspectStat{
+			Database: database,
+			Category: category,
+			Size:     uint64(s.size),
+			Count:    uint64(s.count),
+		}
+		last := -1
+		for i, n := range s.hist {
+			if n > 0 {
+				last = i
+			}
+		}
+		if last >= 0 {
+			result.Histogram = make([]uint64, last+1)
+			copy(result.Histogram, s.hist[:last+1])
+		}
+		if sampling {
+			result.Size = uint64(float64(result.Size) / config.Sample)
+			result.Count = uint64(float64(result.Count) / config.Sample)
+			for i := range result.Histogram {
+				result.Histogram[i] = uint64(float64(result.Histogram[i]) / config.Sample)
+			}
+		}
+		result.Largest = append([]InspectKey{}, s.top...)
+		sort.Slice(result.Largest, func(i, j int) bool { return result.Largest[i].Size > result.Largest[j].Size })
+		if len(result.Largest) == 0 {
+			result.Largest = nil
 		}
+		report.Total += result.Size
+		return result
 	}
-	// Get number of ancient rows inside the freezer
-	ancients := counter(0)
-	if count, err := db.Ancients(); err == nil {
-		ancients = counter(count)
+	for _, category := range inspectCategories {
+		report.Stats = append(report.Stats, export(category[0], category[1], stats[category[1]]))
+	}
+	report.Unaccounted = export("Key-Value store", "Unaccounted", &unaccounted)
+
+	// Inspect append-only file store then, unless only some keys were requested.
+	if len(config.Prefixes) == 0 {
+		var (
+			ancients, _ = db.Ancients()
+			tail, _     = db.Tail()
+		)
+		tables := []struct {
+			table, category string
+			pruned          bool
+		}{
+			{freezerHeaderTable, "Headers", false},
+			{freezerBodiesTable, "Bodies", true},
+			{freezerReceiptTable, "Receipt lists", true},
+			{freezerDifficultyTable, "Difficulties", false},
+			{freezerHashTable, "Block number->hash", false},
+		}
+		for _, table := range tables {
+			size, err := db.AncientSize(table.table)
+			if err != nil {
+				continue
+			}
+			items := ancients
+			if table.pruned && tail < ancients {
+				items -= tail
+			}
+			report.Stats = append(report.Stats, &InspectStat{
+				Database: "Ancient store",
+				Category: table.category,
+				Size:     size,
+				Count:    items,
+			})
+			report.Total += size
+		}
+	}
+	return report, nil
+}
+
+// InspectDatabase traverses the database and prints the size of all different
+// categories of data, either as tables or as JSON.
+func InspectDatabase(db odfdb.Database, config *InspectConfig) error {
+	if config == nil {
+		config = new(InspectConfig)
+	}
+	report, err := CollectDatabaseStats(db, config)
+	if err != nil {
+		return err
+	}
+	if config.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
 	}
 	// Display the database statistic.
-	stats := [][]string{
-		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
-		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
-		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
-		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
-		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
-		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
-		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
-		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
-		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
-		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
-		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
-		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
-		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
-		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
-		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
-		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
-		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
-		{"Ancient store", "Receipt lists", ancientReceiptsSize.String(), ancients.String()},
-		{"Ancient store", "Difficulties", ancientTdsSize.String(), ancients.String()},
-		{"Ancient store", "Block number->hash", ancientHashesSize.String(), ancients.String()},
-		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
-		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
+	var stats [][]string
+	for _, s := range report.Stats {
+		stats = append(stats, []string{s.Database, s.Category, common.StorageSize(s.Size).String(), counter(s.Count).String()})
 	}
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
-	table.SetFooter([]string{"", "Total", total.String(), " "})
+	table.SetFooter([]string{"", "Total", common.StorageSize(report.Total).String(), " "})
 	table.AppendBulk(stats)
 	table.Render()
 
-	if unaccounted.size > 0 {
-		log.Error("Database contains unaccounted data", "size", unaccounted.size, "count", unaccounted.count)
+	if report.Sample > 0 {
+		fmt.Printf("Key-value store sizes estimated from %.2f%% of the key space\n", report.Sample*100)
+	}
+	// Display the size histograms and the largest entries if requested
+	if config.Histogram {
+		var rows [][]string
+		for _, s := range append(report.Stats, report.Unaccounted) {
+			for i, n := range s.Histogram {
+				if n == 0 {
+					continue
+				}
+				bucket := fmt.Sprintf("%v - %v", common.StorageSize(uint64(1)<<i), common.StorageSize(uint64(1)<<(i+1)))
+				rows = append(rows, []string{s.Database, s.Category, bucket, counter(n).String()})
+			}
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Database", "Category", "Entry size", "Items"})
+		table.AppendBulk(rows)
+		table.Render()
+	}
+	if config.Top > 0 {
+		var rows [][]string
+		for _, s := range append(report.Stats, report.Unaccounted) {
+			for _, entry := range s.Largest {
+				rows = append(rows, []string{s.Database, s.Category, entry.Key.String(), common.StorageSize(entry.Size).String()})
+			}
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Database", "Category", "Key", "Size"})
+		table.AppendBulk(rows)
+		table.Render()
+	}
+	if report.Unaccounted.Count > 0 {
+		log.Error("Database contains unaccounted data", "size", common.StorageSize(report.Unaccounted.Size), "count", report.Unaccounted.Count)
 	}
-
 	return nil
 }
@@ -0,0 +1,174 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/odf/go-odf/common"
+)
+
+// Tests that the database inspection accounts the entries into the correct
+// categories, and that prefix filtering, top lists and sampling work.
+func TestCollectDatabaseStats(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	// Fill the database with trie nodes, codes, transaction lookups and junk
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		var hash common.Hash
+		rnd.Read(hash[:])
+		if hash[0] == headerPrefix[0] {
+			hash[0]++ // Might be mistaken for a header metadata entry
+		}
+		db.Put(hash[:], make([]byte, 100))
+	}
+	for i := 0; i < 100; i++ {
+		var hash common.Hash
+		rnd.Read(hash[:])
+		db.Put(codeKey(hash), make([]byte, 1000+i))
+		db.Put(txLookupKey(hash), []byte{byte(i)})
+	}
+	db.Put([]byte("junk"), []byte("junk"))
+
+	// Inspect the whole database and check the results
+	report, err := CollectDatabaseStats(db, &InspectConfig{Top: 3})
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	stats := make(map[string]*InspectStat)
+	for _, s := range report.Stats {
+		stats[s.Category] = s
+	}
+	if have := stats["Trie nodes"].Count; have != 5000 {
+		t.Errorf("trie node count mismatch: have %d, want %d", have, 5000)
+	}
+	if have, want := stats["Trie nodes"].Histogram, []uint64{0, 0, 0, 0, 0, 0, 0, 5000}; len(have) != len(want) || have[7] != want[7] {
+		t.Errorf("trie node histogram mismatch: have %v, want %v", have, want)
+	}
+	if have := stats["Contract codes"].Count; have != 100 {
+		t.Errorf("code count mismatch: have %d, want %d", have, 100)
+	}
+	if have := len(stats["Contract codes"].Largest); have != 3 {
+		t.Fatalf("largest code count mismatch: have %d, want %d", have, 3)
+	}
+	for i, entry := range stats["Contract codes"].Largest {
+		if want := uint64(len(codePrefix) + common.HashLength + 1099 - i); entry.Size != want {
+			t.Errorf("largest code %d: size mismatch: have %d, want %d", i, entry.Size, want)
+		}
+	}
+	if have := report.Unaccounted.Count; have != 1 {
+		t.Errorf("unaccounted count mismatch: have %d, want %d", have, 1)
+	}
+	// Restrict the inspection to the codes
+	report, err = CollectDatabaseStats(db, &InspectConfig{Prefixes: [][]byte{codePrefix}})
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	for _, s := range report.Stats {
+		switch s.Category {
+		case "Contract codes":
+			if s.Count != 100 {
+				t.Errorf("filtered code count mismatch: have %d, want %d", s.Count, 100)
+			}
+		case "Transaction index":
+			if s.Count != 0 {
+				t.Errorf("unexpected transaction lookups with prefix filter: %d", s.Count)
+			}
+		}
+	}
+	// Sample a fraction of the database and check the estimate is close
+	report, err = CollectDatabaseStats(db, &InspectConfig{Sample: 0.25})
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	for _, s := range report.Stats {
+		if s.Category == "Trie nodes" && (s.Count < 4500 || s.Count > 5500) {
+			t.Errorf("sampled trie node count too far off: have %d, want ~%d", s.Count, 5000)
+		}
+	}
+}
+
+// Tests that a persistent database remembers the engine it was created with and
+// refuses to be reopened with a different one.
+func TestOpenKeyValueStoreEngineMismatch(t *testing.T) {
+	for _, engine := range []string{DBLeveldb, DBPebble} {
+		dir, err := ioutil.TempDir("", "rawdb-engine-")
+		if err != nil {
+			t.Fatalf("failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		db, err := openKeyValueStore(engine, dir, 16, 16, "")
+		if err != nil {
+			t.Fatalf("%s: failed to create database: %v", engine, err)
+		}
+		if stored := ReadDatabaseEngine(db); stored != engine {
+			t.Errorf("%s: stored engine mismatch: have %q, want %q", engine, stored, engine)
+		}
+		db.Close()
+
+		if have := PreexistingDatabase(dir); have != engine {
+			t.Errorf("%s: detected engine mismatch: have %q, want %q", engine, have, engine)
+		}
+		other := DBPebble
+		if engine == DBPebble {
+			other = DBLeveldb
+		}
+		if _, err := openKeyValueStore(other, dir, 16, 16, ""); err == nil {
+			t.Errorf("%s: opened with engine %q", engine, other)
+		}
+		// An unspecified engine must pick up the existing one
+		db, err = openKeyValueStore("", dir, 16, 16, "")
+		if err != nil {
+			t.Fatalf("%s: failed to reopen database: %v", engine, err)
+		}
+		db.Close()
+	}
+}
+
+// Tests that the freezer checksum option only applies to newly created tables,
+// while existing ones keep the format they were created with.
+func TestDatabaseWithFreezerChecksums(t *testing.T) {
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	check := func(checksums bool, want bool) {
+		db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", checksums)
+		if err != nil {
+			t.Fatalf("failed to open database with checksums %v: %v", checksums, err)
+		}
+		defer db.Close()
+
+		for name, table := range db.(*freezerdb).AncientStore.(*freezer).tables {
+			if table.checksums != want {
+				t.Errorf("table %s, checksums requested %v: checksum format mismatch: have %v, want %v", name, checksums, table.checksums, want)
+			}
+		}
+	}
+	check(false, false) // Fresh tables without checksums
+	check(true, false)  // Existing tables can't be migrated in place
+	os.RemoveAll(frdir)
+	check(true, true) // Resynced tables pick the checksums up
+	check(false, true)
+}
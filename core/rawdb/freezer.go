@@ -22,6 +22,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -83,8 +84,10 @@ type freezer struct {
 }
 
 // newFreezer creates a chain freezer that moves ancient chain data into
-// append-only flat file containers.
-func newFreezer(datadir string, namespace string) (*freezer, error) {
+// append-only flat file containers. The checksums flag enables item checksums
+// for newly created tables, existing ones retain the format they were created
+// with.
+func newFreezer(datadir string, namespace string, checksums bool) (*freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -113,7 +116,7 @@ func newFreezer(datadir string, namespace string) (*freezer, error) {
 		quit:         make(chan struct{}),
 	}
 	for name, disableSnappy := range freezerNoSnappy {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, disableSnappy)
+		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, disableSnappy, checksums)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
@@ -130,7 +133,21 @@ func newFreezer(datadir string, namespace string) (*freezer, error) {
 		lock.Release()
 		return nil, err
 	}
-	log.Info("Opened ancient database", "database", datadir)
+	// Item checksums can't be added to existing tables, so the integrity checks
+	// of tables created without them are limited to the block cross-checks
+	if checksums {
+		var legacy []string
+		for name, table := range freezer.tables {
+			if !table.checksums {
+				legacy = append(legacy, name)
+			}
+		}
+		if len(legacy) > 0 {
+			sort.Strings(legacy)
+			log.Warn("Ancient tables lack item checksums, resync the ancient store to enable them", "tables", legacy)
+		}
+	}
+	log.Info("Opened ancient database", "database", datadir, "checksums", checksums)
 	return freezer, nil
 }
 
@@ -176,6 +193,18 @@ func (f *freezer) Ancients() (uint64, error) {
 	return atomic.LoadUint64(&f.frozen), nil
 }
 
+// Tail returns the number of the first block whose body and receipts are still
+// retained in the freezer, all below it were pruned.
+func (f *freezer) Tail() (uint64, error) {
+	var tail uint64
+	for _, name := range freezerPrunableTables {
+		if t := f.tables[name].tail(); t > tail {
+			tail = t
+		}
+	}
+	return tail, nil
+}
+
 // AncientSize returns the ancient size of the specified category.
 func (f *freezer) AncientSize(kind string) (uint64, error) {
 	if table := f.tables[kind]; table != nil {
@@ -236,6 +265,13 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	if atomic.LoadUint64(&f.frozen) <= items {
 		return nil
 	}
+	// Make sure all tables can be truncated before touching any of them, a half
+	// truncated freezer would be out of sync
+	for _, table := range f.tables {
+		if table.closed() {
+			return errClosed
+		}
+	}
 	for _, table := range f.tables {
 		if err := table.truncate(items); err != nil {
 			return err
@@ -245,6 +281,20 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	return nil
 }
 
+// TruncateTail discards the block bodies and receipts below the provided
+// threshold number. Headers, hashes and difficulties are left untouched.
+func (f *freezer) TruncateTail(tail uint64) error {
+	if frozen := atomic.LoadUint64(&f.frozen); tail > frozen {
+		tail = frozen
+	}
+	for _, name := range freezerPrunableTables {
+		if err := f.tables[name].truncateTail(tail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Sync flushes all data tables to disk.
 func (f *freezer) Sync() error {
 	var errs []error
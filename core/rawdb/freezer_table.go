@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"path/filepath"
@@ -43,22 +44,42 @@ var (
 
 	// errNotSupported is returned if the database doesn't support the required operation.
 	errNotSupported = errors.New("this operation is not supported")
+
+	// errChecksumMismatch is returned if the data of an item retrieved from the
+	// freezer table doesn't match the checksum stored in its index entry.
+	errChecksumMismatch = errors.New("checksum mismatch")
 )
 
+// crc32cTable is the Castagnoli polynomial table used for item checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
 // indexEntry contains the number/id of the file that the data resides in, aswell as the
 // offset within the file to the end of the data
 // In serialized form, the filenum is stored as uint16.
 type indexEntry struct {
-	filenum uint32 // stored as uint16 ( 2 bytes)
-	offset  uint32 // stored as uint32 ( 4 bytes)
+	filenum  uint32 // stored as uint16 ( 2 bytes)
+	offset   uint32 // stored as uint32 ( 4 bytes)
+	checksum uint32 // stored as uint32 ( 4 bytes), only in checksummed tables
 }
 
-const indexEntrySize = 6
+const (
+	indexEntrySize         = 6                  // Size of a legacy index entry
+	checksumIndexEntrySize = indexEntrySize + 4 // Size of an index entry carrying a checksum
+)
+
+// Table formats stored in the metadata file, defining the index entry layout.
+const (
+	tableFormatLegacy   = 0 // Index entries without item checksums
+	tableFormatChecksum = 1 // Index entries with a CRC32-C checksum of the stored item
+)
 
 // unmarshallBinary deserializes binary b into the rawIndex entry.
 func (i *indexEntry) unmarshalBinary(b []byte) error {
 	i.filenum = uint32(binary.BigEndian.Uint16(b[:2]))
 	i.offset = binary.BigEndian.Uint32(b[2:6])
+	if len(b) >= checksumIndexEntrySize {
+		i.checksum = binary.BigEndian.Uint32(b[6:10])
+	}
 	return nil
 }
 
@@ -77,9 +98,12 @@ type freezerTable struct {
 	// WARNING: The `items` field is accessed atomically. On 32 bit platforms, only
 	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
 	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
-	items uint64 // Number of items stored in the table (including items removed from tail)
+	items      uint64 // Number of items stored in the table (including items removed from tail)
+	itemHidden uint64 // Number of items hidden from the tail, at least the discarded ones
 
 	noCompression bool   // if true, disables snappy compression. Note: does not work retroactively
+	checksums     bool   // if true, index entries carry item checksums. Note: does not work retroactively
+	entrySize     int64  // Size of a serialized index entry, depending on the table format
 	maxFileSize   uint32 // Max file size for data-files
 	name          string
 	path          string
@@ -89,6 +113,7 @@ type freezerTable struct {
 	headId uint32              // number of the currently active head file
 	tailId uint32              // number of the earliest file
 	index  *os.File            // File descriptor for the indexEntry file of the table
+	meta   *os.File            // File descriptor for the persisted tail marker and format of the table
 
 	// In the case that old items are deleted (from the tail), we use itemOffset
 	// to count how many historic items have gone missing.
@@ -104,8 +129,8 @@ type freezerTable struct {
 }
 
 // newTable opens a freezer table with default settings - 2G files
-func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, disableSnappy bool) (*freezerTable, error) {
-	return newCustomTable(path, name, readMeter, writeMeter, sizeGauge, 2*1000*1000*1000, disableSnappy)
+func newTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, disableSnappy bool, checksums bool) (*freezerTable, error) {
+	return newCustomTable(path, name, readMeter, writeMeter, sizeGauge, 2*1000*1000*1000, disableSnappy, checksums)
 }
 
 // openFreezerFileForAppend opens a freezer table file and seeks to the end
@@ -149,7 +174,10 @@ func truncateFreezerFile(file *os.File, size int64) error {
 // newCustomTable opens a freezer table, creating the data and index files if they are
 // non existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
-func newCustomTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool) (*freezerTable, error) {
+//
+// The checksums flag only has an effect on newly created tables, existing ones
+// retain the index format they were created with.
+func newCustomTable(path string, name string, readMeter metrics.Meter, writeMeter metrics.Meter, sizeGauge metrics.Gauge, maxFilesize uint32, noCompression bool, checksums bool) (*freezerTable, error) {
 	// Ensure the containing directory exists and open the indexEntry file
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
@@ -166,9 +194,15 @@ func newCustomTable(path string, name string, readMeter metrics.Meter, writeMete
 	if err != nil {
 		return nil, err
 	}
+	meta, err := openFreezerFileForAppend(filepath.Join(path, fmt.Sprintf("%s.meta", name)))
+	if err != nil {
+		offsets.Close()
+		return nil, err
+	}
 	// Create the table and repair any past inconsistency
 	tab := &freezerTable{
 		index:         offsets,
+		meta:          meta,
 		files:         make(map[uint32]*os.File),
 		readMeter:     readMeter,
 		writeMeter:    writeMeter,
@@ -177,6 +211,7 @@ func newCustomTable(path string, name string, readMeter metrics.Meter, writeMete
 		path:          path,
 		logger:        log.New("database", path, "table", name),
 		noCompression: noCompression,
+		checksums:     checksums,
 		maxFileSize:   maxFilesize,
 	}
 	if err := tab.repair(); err != nil {
@@ -197,21 +232,40 @@ func newCustomTable(path string, name string, readMeter metrics.Meter, writeMete
 // repair cross checks the head and the index file and truncates them to
 // be in sync with each other after a potential crash / data loss.
 func (t *freezerTable) repair() error {
-	// Create a temporary offset buffer to init files with and read indexEntry into
-	buffer := make([]byte, indexEntrySize)
-
-	// If we've just created the files, initialize the index with the 0 indexEntry
+	// Determine the index format: new tables use the requested one, existing
+	// tables the one they were created with
 	stat, err := t.index.Stat()
 	if err != nil {
 		return err
 	}
+	if stat.Size() == 0 {
+		if t.checksums {
+			if err := t.writeFormat(tableFormatChecksum); err != nil {
+				return err
+			}
+		}
+	} else {
+		format, err := t.readFormat()
+		if err != nil {
+			return err
+		}
+		t.checksums = format == tableFormatChecksum
+	}
+	t.entrySize = indexEntrySize
+	if t.checksums {
+		t.entrySize = checksumIndexEntrySize
+	}
+	// Create a temporary offset buffer to init files with and read indexEntry into
+	buffer := make([]byte, t.entrySize)
+
+	// If we've just created the files, initialize the index with the 0 indexEntry
 	if stat.Size() == 0 {
 		if _, err := t.index.Write(buffer); err != nil {
 			return err
 		}
 	}
-	// Ensure the index is a multiple of indexEntrySize bytes
-	if overflow := stat.Size() % indexEntrySize; overflow != 0 {
+	// Ensure the index is a multiple of entrySize bytes
+	if overflow := stat.Size() % t.entrySize; overflow != 0 {
 		truncateFreezerFile(t.index, stat.Size()-overflow) // New file can't trigger this path
 	}
 	// Retrieve the file sizes and prepare for truncation
@@ -235,7 +289,14 @@ func (t *freezerTable) repair() error {
 	t.tailId = firstIndex.filenum
 	t.itemOffset = firstIndex.offset
 
-	t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
+	// Remove any data files left behind by an interrupted tail truncation
+	for id := t.tailId; id > 0; id-- {
+		if err := os.Remove(filepath.Join(t.path, t.fileName(id-1))); err != nil {
+			break
+		}
+	}
+
+	t.index.ReadAt(buffer, offsetsSize-t.entrySize)
 	lastIndex.unmarshalBinary(buffer)
 	t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForAppend)
 	if err != nil {
@@ -261,11 +322,11 @@ func (t *freezerTable) repair() error {
 		// Truncate the index to point within the head file
 		if contentExp > contentSize {
 			t.logger.Warn("Truncating dangling indexes", "indexed", common.StorageSize(contentExp), "stored", common.StorageSize(contentSize))
-			if err := truncateFreezerFile(t.index, offsetsSize-indexEntrySize); err != nil {
+			if err := truncateFreezerFile(t.index, offsetsSize-t.entrySize); err != nil {
 				return err
 			}
-			offsetsSize -= indexEntrySize
-			t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
+			offsetsSize -= t.entrySize
+			t.index.ReadAt(buffer, offsetsSize-t.entrySize)
 			var newLastIndex indexEntry
 			newLastIndex.unmarshalBinary(buffer)
 			// We might have slipped back into an earlier head-file here
@@ -294,18 +355,91 @@ func (t *freezerTable) repair() error {
 		return err
 	}
 	// Update the item and byte counters and return
-	t.items = uint64(t.itemOffset) + uint64(offsetsSize/indexEntrySize-1) // last indexEntry points to the end of the data file
+	t.items = uint64(t.itemOffset) + uint64(offsetsSize/t.entrySize-1) // last indexEntry points to the end of the data file
 	t.headBytes = uint32(contentSize)
 	t.headId = lastIndex.filenum
 
+	// Load the hidden tail marker, it can't point below the discarded items
+	// nor beyond the head (e.g. if the head was truncated in the meantime)
+	t.itemHidden = uint64(t.itemOffset)
+	if hidden, err := t.readTail(); err != nil {
+		return err
+	} else if hidden > t.itemHidden {
+		t.itemHidden = hidden
+	}
+	if t.itemHidden > t.items {
+		t.itemHidden = t.items
+	}
+
 	// Close opened files and preopen all files
 	if err := t.preopen(); err != nil {
 		return err
 	}
-	t.logger.Debug("Chain freezer table opened", "items", t.items, "size", common.StorageSize(t.headBytes))
+	t.logger.Debug("Chain freezer table opened", "items", t.items, "tail", t.itemHidden, "checksums", t.checksums, "size", common.StorageSize(t.headBytes))
 	return nil
 }
 
+// readTail retrieves the persisted tail marker of the table, or zero if none
+// was stored yet.
+func (t *freezerTable) readTail() (uint64, error) {
+	buffer := make([]byte, 8)
+	if _, err := t.meta.ReadAt(buffer, 0); err != nil {
+		if err == io.EOF {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buffer), nil
+}
+
+// writeTail persists the tail marker of the table, flushing it to disk.
+func (t *freezerTable) writeTail(tail uint64) error {
+	buffer := make([]byte, 8)
+	binary.BigEndian.PutUint64(buffer, tail)
+	if _, err := t.meta.WriteAt(buffer, 0); err != nil {
+		return err
+	}
+	return t.meta.Sync()
+}
+
+// readFormat retrieves the index format of the table from the metadata file,
+// which defaults to the legacy format for tables created without one.
+func (t *freezerTable) readFormat() (uint16, error) {
+	buffer := make([]byte, 2)
+	if _, err := t.meta.ReadAt(buffer, 8); err != nil {
+		if err == io.EOF {
+			return tableFormatLegacy, nil
+		}
+		return 0, err
+	}
+	format := binary.BigEndian.Uint16(buffer)
+	if format != tableFormatLegacy && format != tableFormatChecksum {
+		return 0, fmt.Errorf("unsupported freezer table format %d", format)
+	}
+	return format, nil
+}
+
+// writeFormat persists the index format of the table after the tail marker,
+// flushing it to disk.
+func (t *freezerTable) writeFormat(format uint16) error {
+	buffer := make([]byte, 2)
+	binary.BigEndian.PutUint16(buffer, format)
+	if _, err := t.meta.WriteAt(buffer, 8); err != nil {
+		return err
+	}
+	return t.meta.Sync()
+}
+
+// marshalEntry serializes an index entry in the layout used by the table.
+func (t *freezerTable) marshalEntry(entry *indexEntry) []byte {
+	b := entry.marshallBinary()
+	if t.checksums {
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[indexEntrySize:], entry.checksum)
+	}
+	return b
+}
+
 // preopen opens all files that the freezer will need. This modfod should be called from an init-context,
 // since it assumes that it doesn't have to bother with locking
 // The rationale for doing preopen is to not have to do it from within Retrieve, thus not needing to ever
@@ -329,6 +463,9 @@ func (t *freezerTable) truncate(items uint64) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
 	// If our item count is correct, don't do anything
 	existing := atomic.LoadUint64(&t.items)
 	if existing <= items {
@@ -345,16 +482,29 @@ func (t *freezerTable) truncate(items uint64) error {
 		log = t.logger.Warn // Only loud warn if we delete multiple items
 	}
 	log("Truncating freezer table", "items", existing, "limit", items)
-	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
+
+	// If the limit falls below the discarded tail, none of the stored items
+	// survive: reset the table to an empty one starting at the limit
+	if items < uint64(t.itemOffset) {
+		if err := t.resetTo(items); err != nil {
+			return err
+		}
+		newSize, err := t.sizeNolock()
+		if err != nil {
+			return err
+		}
+		t.sizeGauge.Dec(int64(oldSize - newSize))
+		return nil
+	}
+	relative := items - uint64(t.itemOffset)
+	if err := truncateFreezerFile(t.index, int64(relative+1)*t.entrySize); err != nil {
 		return err
 	}
 	// Calculate the new expected size of the data file and truncate it
-	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+	expected, err := t.readEntry(relative)
+	if err != nil {
 		return err
 	}
-	var expected indexEntry
-	expected.unmarshalBinary(buffer)
 
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
@@ -377,6 +527,9 @@ func (t *freezerTable) truncate(items uint64) error {
 	// All data files truncated, set internal counters and return
 	atomic.StoreUint64(&t.items, items)
 	atomic.StoreUint32(&t.headBytes, expected.offset)
+	if atomic.LoadUint64(&t.itemHidden) > items {
+		atomic.StoreUint64(&t.itemHidden, items)
+	}
 
 	// Retrieve the new size and update the total size counter
 	newSize, err := t.sizeNolock()
@@ -388,6 +541,196 @@ func (t *freezerTable) truncate(items uint64) error {
 	return nil
 }
 
+// resetTo discards all the items of the table, leaving it empty with the next
+// item to be appended being the given one. It is used when truncating below the
+// discarded tail, where no stored item can be retained. The caller must hold the
+// write lock.
+func (t *freezerTable) resetTo(items uint64) error {
+	// Persist the new tail before touching any data, so a crash can't resurrect
+	// the discarded items
+	if err := t.writeTail(items); err != nil {
+		return err
+	}
+	// Rewrite the index with a single entry, carrying the current head file as
+	// the new tail and the number of items preceding it
+	name := t.index.Name()
+	index, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	head := indexEntry{
+		filenum: t.headId,
+		offset:  uint32(items),
+	}
+	if _, err := index.Write(t.marshalEntry(&head)); err != nil {
+		index.Close()
+		return err
+	}
+	if err := index.Sync(); err != nil {
+		index.Close()
+		return err
+	}
+	index.Close()
+
+	t.index.Close()
+	renameErr := os.Rename(name+".tmp", name)
+	if t.index, err = openFreezerFileForAppend(name); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	// Empty the head file and delete all the earlier ones
+	if err := truncateFreezerFile(t.head, 0); err != nil {
+		return err
+	}
+	for id := t.tailId; id < t.headId; id++ {
+		t.releaseFile(id)
+		if err := os.Remove(filepath.Join(t.path, t.fileName(id))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	t.tailId = t.headId
+	t.itemOffset = head.offset
+
+	atomic.StoreUint64(&t.items, items)
+	atomic.StoreUint64(&t.itemHidden, items)
+	atomic.StoreUint32(&t.headBytes, 0)
+	return nil
+}
+
+// truncateTail discards any ancient data below the provided threshold number.
+// The items are hidden from readers right away, while the data files holding
+// nothing but hidden items are deleted from disk.
+func (t *freezerTable) truncateTail(tail uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	// If our tail is already beyond the requested one, don't do anything
+	if atomic.LoadUint64(&t.itemHidden) >= tail {
+		return nil
+	}
+	items := atomic.LoadUint64(&t.items)
+	if tail > items {
+		tail = items
+	}
+	// Persist the new tail before touching any data, so a crash can't resurrect
+	// the discarded items
+	if err := t.writeTail(tail); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&t.itemHidden, tail)
+
+	// Find the data file holding the new tail item, all before it can go
+	newTailId := t.headId
+	if tail < items {
+		entry, err := t.readEntry(tail - uint64(t.itemOffset) + 1)
+		if err != nil {
+			return err
+		}
+		newTailId = entry.filenum
+	}
+	if newTailId == t.tailId {
+		return nil
+	}
+	oldSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	// Find the first item stored in the new tail file. The file numbers in the
+	// index are ascending, so a binary search does the trick.
+	var (
+		lo = uint64(0)
+		hi = items - uint64(t.itemOffset)
+	)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		entry, err := t.readEntry(mid + 1)
+		if err != nil {
+			return err
+		}
+		if entry.filenum >= newTailId {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	first := lo
+
+	// Rewrite the index without the discarded items. The first entry carries the
+	// new tail file and the number of discarded items.
+	name := t.index.Name()
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	index, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	head := indexEntry{
+		filenum: newTailId,
+		offset:  uint32(uint64(t.itemOffset) + first),
+	}
+	if _, err := index.Write(t.marshalEntry(&head)); err != nil {
+		index.Close()
+		return err
+	}
+	start := int64(first+1) * t.entrySize
+	if _, err := io.Copy(index, io.NewSectionReader(t.index, start, stat.Size()-start)); err != nil {
+		index.Close()
+		return err
+	}
+	if err := index.Sync(); err != nil {
+		index.Close()
+		return err
+	}
+	index.Close()
+
+	t.index.Close()
+	renameErr := os.Rename(name+".tmp", name)
+	if t.index, err = openFreezerFileForAppend(name); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	// Delete the data files which became unreachable
+	for id := t.tailId; id < newTailId; id++ {
+		t.releaseFile(id)
+		if err := os.Remove(filepath.Join(t.path, t.fileName(id))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	t.tailId = newTailId
+	t.itemOffset = head.offset
+
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Dec(int64(oldSize - newSize))
+	t.logger.Debug("Truncated freezer table tail", "tail", tail, "discarded", t.itemOffset, "file", newTailId)
+	return nil
+}
+
+// readEntry retrieves the index entry at the given position of the index file,
+// which is relative to the discarded items. Entry n marks the end of item n-1.
+func (t *freezerTable) readEntry(n uint64) (indexEntry, error) {
+	var (
+		buffer = make([]byte, t.entrySize)
+		entry  indexEntry
+	)
+	if _, err := t.index.ReadAt(buffer, int64(n)*t.entrySize); err != nil {
+		return entry, err
+	}
+	entry.unmarshalBinary(buffer)
+	return entry, nil
+}
+
 // Close closes all opened files.
 func (t *freezerTable) Close() error {
 	t.lock.Lock()
@@ -399,6 +742,10 @@ func (t *freezerTable) Close() error {
 	}
 	t.index = nil
 
+	if err := t.meta.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
 	for _, f := range t.files {
 		if err := f.Close(); err != nil {
 			errs = append(errs, err)
@@ -416,13 +763,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(filepath.Join(t.path, t.fileName(num)))
 		if err != nil {
 			return nil, err
 		}
@@ -431,6 +772,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// fileName returns the name of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return fmt.Sprintf("%s.%04d.rdat", t.name, num)
+	}
+	return fmt.Sprintf("%s.%04d.cdat", t.name, num)
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -511,30 +860,33 @@ func (t *freezerTable) Append(item uint64, blob []byte) error {
 		filenum: atomic.LoadUint32(&t.headId),
 		offset:  newOffset,
 	}
+	if t.checksums {
+		idx.checksum = crc32.Checksum(blob, crc32cTable)
+	}
 	// Write indexEntry
-	t.index.Write(idx.marshallBinary())
+	t.index.Write(t.marshalEntry(&idx))
 
-	t.writeMeter.Mark(int64(bLen + indexEntrySize))
-	t.sizeGauge.Inc(int64(bLen + indexEntrySize))
+	t.writeMeter.Mark(int64(bLen) + t.entrySize)
+	t.sizeGauge.Inc(int64(bLen) + t.entrySize)
 
 	atomic.AddUint64(&t.items, 1)
 	return nil
 }
 
 // getBounds returns the indexes for the item
-// returns start, end, filenumber and error
-func (t *freezerTable) getBounds(item uint64) (uint32, uint32, uint32, error) {
-	buffer := make([]byte, indexEntrySize)
+// returns start, end, filenumber, checksum and error
+func (t *freezerTable) getBounds(item uint64) (uint32, uint32, uint32, uint32, error) {
+	buffer := make([]byte, t.entrySize)
 	var startIdx, endIdx indexEntry
 	// Read second index
-	if _, err := t.index.ReadAt(buffer, int64((item+1)*indexEntrySize)); err != nil {
-		return 0, 0, 0, err
+	if _, err := t.index.ReadAt(buffer, int64(item+1)*t.entrySize); err != nil {
+		return 0, 0, 0, 0, err
 	}
 	endIdx.unmarshalBinary(buffer)
 	// Read first index (unless it's the very first item)
 	if item != 0 {
-		if _, err := t.index.ReadAt(buffer, int64(item*indexEntrySize)); err != nil {
-			return 0, 0, 0, err
+		if _, err := t.index.ReadAt(buffer, int64(item)*t.entrySize); err != nil {
+			return 0, 0, 0, 0, err
 		}
 		startIdx.unmarshalBinary(buffer)
 	} else {
@@ -543,15 +895,15 @@ func (t *freezerTable) getBounds(item uint64) (uint32, uint32, uint32, error) {
 		// only support deletion by files, so that the assumption is held).
 		// This means we can use the first item metadata to carry information about
 		// the 'global' offset, for the deletion-case
-		return 0, endIdx.offset, endIdx.filenum, nil
+		return 0, endIdx.offset, endIdx.filenum, endIdx.checksum, nil
 	}
 	if startIdx.filenum != endIdx.filenum {
 		// If a piece of data 'crosses' a data-file,
 		// it's actually in one piece on the second data-file.
 		// We return a zero-indexEntry for the second file as start
-		return 0, endIdx.offset, endIdx.filenum, nil
+		return 0, endIdx.offset, endIdx.filenum, endIdx.checksum, nil
 	}
-	return startIdx.offset, endIdx.offset, endIdx.filenum, nil
+	return startIdx.offset, endIdx.offset, endIdx.filenum, endIdx.checksum, nil
 }
 
 // Retrieve looks up the data offset of an item with the given number and retrieves
@@ -567,12 +919,12 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 		t.lock.RUnlock()
 		return nil, errOutOfBounds
 	}
-	// Ensure the item was not deleted from the tail either
-	if uint64(t.itemOffset) > item {
+	// Ensure the item was not deleted or hidden from the tail either
+	if atomic.LoadUint64(&t.itemHidden) > item {
 		t.lock.RUnlock()
 		return nil, errOutOfBounds
 	}
-	startOffset, endOffset, filenum, err := t.getBounds(item - uint64(t.itemOffset))
+	startOffset, endOffset, filenum, checksum, err := t.getBounds(item - uint64(t.itemOffset))
 	if err != nil {
 		t.lock.RUnlock()
 		return nil, err
@@ -589,8 +941,12 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 		return nil, err
 	}
 	t.lock.RUnlock()
-	t.readMeter.Mark(int64(len(blob) + 2*indexEntrySize))
+	t.readMeter.Mark(int64(len(blob)) + 2*t.entrySize)
 
+	// Ensure the stored data wasn't corrupted on disk
+	if t.checksums && crc32.Checksum(blob, crc32cTable) != checksum {
+		return nil, fmt.Errorf("%w: item %d", errChecksumMismatch, item)
+	}
 	if t.noCompression {
 		return blob, nil
 	}
@@ -600,7 +956,20 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 // has returns an indicator whodfer the specified number data
 // exists in the freezer table.
 func (t *freezerTable) has(number uint64) bool {
-	return atomic.LoadUint64(&t.items) > number
+	return atomic.LoadUint64(&t.items) > number && atomic.LoadUint64(&t.itemHidden) <= number
+}
+
+// closed returns whodfer the table was already closed.
+func (t *freezerTable) closed() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.index == nil || t.head == nil
+}
+
+// tail returns the number of the first item still accessible in the table.
+func (t *freezerTable) tail() uint64 {
+	return atomic.LoadUint64(&t.itemHidden)
 }
 
 // size returns the total data size in the freezer table.
@@ -633,14 +1002,14 @@ func (t *freezerTable) Sync() error {
 
 // printIndex is a debug print utility function for testing
 func (t *freezerTable) printIndex() {
-	buf := make([]byte, indexEntrySize)
+	buf := make([]byte, t.entrySize)
 
 	fmt.Printf("|-----------------|\n")
 	fmt.Printf("| fileno | offset |\n")
 	fmt.Printf("|--------+--------|\n")
 
 	for i := uint64(0); ; i++ {
-		if _, err := t.index.ReadAt(buf, int64(i*indexEntrySize)); err != nil {
+		if _, err := t.index.ReadAt(buf, int64(i)*t.entrySize); err != nil {
 			break
 		}
 		var entry indexEntry
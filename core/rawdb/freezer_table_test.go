@@ -18,10 +18,13 @@ package rawdb
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -48,7 +51,7 @@ func TestFreezerBasics(t *testing.T) {
 	// set cutoff at 50 bytes
 	f, err := newCustomTable(os.TempDir(),
 		fmt.Sprintf("unittest-%d", rand.Uint64()),
-		metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true)
+		metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge(), 50, true, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,7 +98,7 @@ func TestFreezerBasicsClosing(t *testing.T) {
 		f          *freezerTable
 		err        error
 	)
-	f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+	f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -104,7 +107,7 @@ func TestFreezerBasicsClosing(t *testing.T) {
 		data := getChunk(15, x)
 		f.Append(uint64(x), data)
 		f.Close()
-		f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -121,7 +124,7 @@ func TestFreezerBasicsClosing(t *testing.T) {
 			t.Fatalf("test %d, got \n%x != \n%x", y, got, exp)
 		}
 		f.Close()
-		f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -135,7 +138,7 @@ func TestFreezerRepairDanglingHead(t *testing.T) {
 	fname := fmt.Sprintf("dangling_headtest-%d", rand.Uint64())
 
 	{ // Fill table
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -164,7 +167,7 @@ func TestFreezerRepairDanglingHead(t *testing.T) {
 	idxFile.Close()
 	// Now open it again
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -186,7 +189,7 @@ func TestFreezerRepairDanglingHeadLarge(t *testing.T) {
 	fname := fmt.Sprintf("dangling_headtest-%d", rand.Uint64())
 
 	{ // Fill a table and close it
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -212,7 +215,7 @@ func TestFreezerRepairDanglingHeadLarge(t *testing.T) {
 	idxFile.Close()
 	// Now open it again
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -233,7 +236,7 @@ func TestFreezerRepairDanglingHeadLarge(t *testing.T) {
 	}
 	// And if we open it, we should now be able to read all of them (new values)
 	{
-		f, _ := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, _ := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		for y := 1; y < 255; y++ {
 			exp := getChunk(15, ^y)
 			got, err := f.Retrieve(uint64(y))
@@ -254,7 +257,7 @@ func TestSnappyDetection(t *testing.T) {
 	fname := fmt.Sprintf("snappytest-%d", rand.Uint64())
 	// Open with snappy
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -267,7 +270,7 @@ func TestSnappyDetection(t *testing.T) {
 	}
 	// Open without snappy
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, false)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, false, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -279,7 +282,7 @@ func TestSnappyDetection(t *testing.T) {
 
 	// Open with snappy
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -311,7 +314,7 @@ func TestFreezerRepairDanglingIndex(t *testing.T) {
 	fname := fmt.Sprintf("dangling_indextest-%d", rand.Uint64())
 
 	{ // Fill a table and close it
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -347,7 +350,7 @@ func TestFreezerRepairDanglingIndex(t *testing.T) {
 	// 45, 45, 15
 	// with 3+3+1 items
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -368,7 +371,7 @@ func TestFreezerTruncate(t *testing.T) {
 	fname := fmt.Sprintf("truncation-%d", rand.Uint64())
 
 	{ // Fill table
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -385,7 +388,7 @@ func TestFreezerTruncate(t *testing.T) {
 	}
 	// Reopen, truncate
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -410,7 +413,7 @@ func TestFreezerRepairFirstFile(t *testing.T) {
 	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
 	fname := fmt.Sprintf("truncationfirst-%d", rand.Uint64())
 	{ // Fill table
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -438,7 +441,7 @@ func TestFreezerRepairFirstFile(t *testing.T) {
 	}
 	// Reopen
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -466,7 +469,7 @@ func TestFreezerReadAndTruncate(t *testing.T) {
 	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
 	fname := fmt.Sprintf("read_truncate-%d", rand.Uint64())
 	{ // Fill table
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -483,7 +486,7 @@ func TestFreezerReadAndTruncate(t *testing.T) {
 	}
 	// Reopen and read all files
 	{
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -512,7 +515,7 @@ func TestOffset(t *testing.T) {
 	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
 	fname := fmt.Sprintf("offset-%d", rand.Uint64())
 	{ // Fill table
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -568,7 +571,7 @@ func TestOffset(t *testing.T) {
 	}
 	// Now open again
 	checkPresent := func(numDeleted uint64) {
-		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true)
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -630,6 +633,148 @@ func TestOffset(t *testing.T) {
 	checkPresent(1000000)
 }
 
+// TestFreezerTruncateTail tests that items can be discarded from the tail of the
+// table, deleting the data files no longer needed, and that the new tail is kept
+// across restarts and head truncations.
+func TestFreezerTruncateTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("truncate-tail-%d", rand.Uint64())
+
+	// Fill a table with 30 items of 15 bytes, resulting in 10 files of 3 items
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 30; x++ {
+		f.Append(uint64(x), getChunk(15, x))
+	}
+	// Discard the first 10 items, the first three files should be gone
+	if err := f.truncateTail(10); err != nil {
+		t.Fatal(err)
+	}
+	check := func(f *freezerTable, tail uint64, items uint64) {
+		t.Helper()
+		if have := f.tail(); have != tail {
+			t.Fatalf("tail mismatch: have %d, want %d", have, tail)
+		}
+		for x := uint64(0); x < tail; x++ {
+			if _, err := f.Retrieve(x); err != errOutOfBounds {
+				t.Fatalf("item %d: expected out of bounds, got %v", x, err)
+			}
+			if f.has(x) {
+				t.Fatalf("item %d: reported present below tail", x)
+			}
+		}
+		for x := tail; x < items; x++ {
+			got, err := f.Retrieve(x)
+			if err != nil {
+				t.Fatalf("item %d: %v", x, err)
+			}
+			if exp := getChunk(15, int(x)); !bytes.Equal(got, exp) {
+				t.Fatalf("item %d: have %x, want %x", x, got, exp)
+			}
+		}
+	}
+	check(f, 10, 30)
+	for i := 0; i < 3; i++ {
+		if _, err := os.Stat(filepath.Join(os.TempDir(), fmt.Sprintf("%s.%04d.rdat", fname, i))); !os.IsNotExist(err) {
+			t.Fatalf("data file %d not deleted: %v", i, err)
+		}
+	}
+	// Moving the tail backwards is a noop
+	if err := f.truncateTail(5); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 10, 30)
+	f.Close()
+
+	// Reopen the table, truncate the head and ensure appends still work
+	f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(f, 10, 30)
+	if err := f.truncate(20); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Append(20, getChunk(15, 20)); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 10, 21)
+
+	// Discard everything, nothing should be retrievable anymore
+	if err := f.truncateTail(100); err != nil {
+		t.Fatal(err)
+	}
+	check(f, 21, 21)
+	f.Close()
+}
+
+// TestFreezerChecksums tests that checksummed tables detect corrupted items on
+// retrieval, and that the table format is retained across restarts.
+func TestFreezerChecksums(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("checksums-%d", rand.Uint64())
+
+	// Fill a table with 9 items of 15 bytes, resulting in 3 files of 3 items
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 9; x++ {
+		f.Append(uint64(x), getChunk(15, x))
+	}
+	f.Close()
+
+	// Flip a bit in the first item of the second data file
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("%s.0001.rdat", fname))
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] ^= 0x01
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Reopen without requesting checksums, the existing format must be retained
+	f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !f.checksums {
+		t.Fatalf("table format not retained")
+	}
+	for x := uint64(0); x < 9; x++ {
+		got, err := f.Retrieve(x)
+		if x == 3 {
+			if !errors.Is(err, errChecksumMismatch) {
+				t.Fatalf("item %d: expected checksum mismatch, got %v", x, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("item %d: %v", x, err)
+		}
+		if exp := getChunk(15, int(x)); !bytes.Equal(got, exp) {
+			t.Fatalf("item %d: have %x, want %x", x, got, exp)
+		}
+	}
+	// Truncating and re-appending should restore a valid checksum
+	if err := f.truncate(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Append(3, getChunk(15, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := f.Retrieve(3); err != nil || !bytes.Equal(got, getChunk(15, 3)) {
+		t.Fatalf("item 3: have %x, %v", got, err)
+	}
+}
+
 // TODO (?)
 // - test that if we remove several head-files, aswell as data last data-file,
 //   the index is truncated accordingly
@@ -640,3 +785,56 @@ func TestOffset(t *testing.T) {
 // However, all 'normal' failure modes arising due to failing to sync() or save a file should be
 // handled already, and the case described above can only (?) happen if an external process/user
 // deletes files from the filesystem.
+
+// TestFreezerTruncateBelowTail tests that truncating a table below its pruned
+// tail resets it to an empty table starting at the new item count, which keeps
+// working after reopening.
+func TestFreezerTruncateBelowTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("truncate-below-tail-%d", rand.Uint64())
+
+	// Fill the table with 30 items spread over multiple files and prune the first 20
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 30; x++ {
+		data := getChunk(15, x)
+		f.Append(uint64(x), data)
+	}
+	if err := f.truncateTail(20); err != nil {
+		t.Fatal(err)
+	}
+	if f.itemOffset == 0 {
+		t.Fatalf("no items discarded from the tail")
+	}
+	// Truncate below the discarded items and append onto the empty table
+	if err := f.truncate(10); err != nil {
+		t.Fatalf("failed to truncate below tail: %v", err)
+	}
+	if items, tail := atomic.LoadUint64(&f.items), f.tail(); items != 10 || tail != 10 {
+		t.Fatalf("table bounds mismatch: have %d/%d, want %d/%d", items, tail, 10, 10)
+	}
+	if _, err := f.Retrieve(9); err == nil {
+		t.Fatalf("item below the reset tail retrieved")
+	}
+	if err := f.Append(10, getChunk(15, 10)); err != nil {
+		t.Fatalf("failed to append after reset: %v", err)
+	}
+	f.Close()
+
+	// Reopen the table and ensure the reset survived
+	f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if items, tail := atomic.LoadUint64(&f.items), f.tail(); items != 11 || tail != 10 {
+		t.Fatalf("reopened table bounds mismatch: have %d/%d, want %d/%d", items, tail, 11, 10)
+	}
+	if blob, err := f.Retrieve(10); err != nil || !bytes.Equal(blob, getChunk(15, 10)) {
+		t.Fatalf("item after reset mismatch: have %x (%v), want %x", blob, err, getChunk(15, 10))
+	}
+}
@@ -30,6 +30,9 @@ var (
 	// databaseVerisionKey tracks the current database version.
 	databaseVerisionKey = []byte("DatabaseVersion")
 
+	// databaseEngineKey tracks the key-value engine the database was created with.
+	databaseEngineKey = []byte("DatabaseEngine")
+
 	// headHeaderKey tracks the latest known header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -51,6 +54,9 @@ var (
 	// snapshotJournalKey tracks the in-memory diff layers across restarts.
 	snapshotJournalKey = []byte("SnapshotJournal")
 
+	// snapshotSyncStatusKey tracks the snapshot sync status across restarts.
+	snapshotSyncStatusKey = []byte("SnapshotSyncStatus")
+
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
@@ -82,6 +88,26 @@ var (
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
 
+// DataPrefixes returns the key prefixes under which the named class of data is
+// stored, for tooling operating on raw key ranges. The flag is false if the name
+// is unknown.
+func DataPrefixes(name string) ([][]byte, bool) {
+	switch name {
+	case "snapshot":
+		return [][]byte{SnapshotAccountPrefix, SnapshotStoragePrefix}, true
+	case "preimages":
+		return [][]byte{preimagePrefix}, true
+	case "bloombits":
+		return [][]byte{bloomBitsPrefix, BloomBitsIndexPrefix}, true
+	case "code":
+		return [][]byte{codePrefix}, true
+	case "txlookup":
+		return [][]byte{txLookupPrefix}, true
+	default:
+		return nil, false
+	}
+}
+
 const (
 	// freezerHeaderTable indicates the name of the freezer header table.
 	freezerHeaderTable = "headers"
@@ -109,6 +135,11 @@ var freezerNoSnappy = map[string]bool{
 	freezerDifficultyTable: true,
 }
 
+// freezerPrunableTables lists the ancient-tables whose old items can be pruned
+// from the tail. Headers, hashes and difficulties are retained to keep the chain
+// verifiable.
+var freezerPrunableTables = []string{freezerBodiesTable, freezerReceiptTable}
+
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
 // fields.
 type LegacyTxLookupEntry struct {
@@ -74,6 +74,12 @@ func (t *table) AncientSize(kind string) (uint64, error) {
 	return t.db.AncientSize(kind)
 }
 
+// Tail is a noop passthrough that just forwards the request to the underlying
+// database.
+func (t *table) Tail() (uint64, error) {
+	return t.db.Tail()
+}
+
 // AppendAncient is a noop passthrough that just forwards the request to the underlying
 // database.
 func (t *table) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
@@ -86,6 +92,12 @@ func (t *table) TruncateAncients(items uint64) error {
 	return t.db.TruncateAncients(items)
 }
 
+// TruncateTail is a noop passthrough that just forwards the request to the
+// underlying database.
+func (t *table) TruncateTail(tail uint64) error {
+	return t.db.TruncateTail(tail)
+}
+
 // Sync is a noop passthrough that just forwards the request to the underlying
 // database.
 func (t *table) Sync() error {
@@ -152,6 +164,16 @@ func (t *table) Compact(start []byte, limit []byte) error {
 	return t.db.Compact(start, limit)
 }
 
+// NewSnapshot creates a database snapshot based on the current state, each
+// read prefixing all keys with the pre-configured string.
+func (t *table) NewSnapshot() (odfdb.Snapshot, error) {
+	snap, err := t.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{snap, t.prefix}, nil
+}
+
 // NewBatch creates a write-only database that buffers changes to its host db
 // until a final write is called, each operation prefixing all keys with the
 // pre-configured string.
@@ -215,6 +237,28 @@ func (b *tableBatch) Replay(w odfdb.KeyValueWriter) error {
 	return b.batch.Replay(&tableReplayer{w: w, prefix: b.prefix})
 }
 
+// tableSnapshot is a wrapper around a database snapshot that prefixes each key
+// access with a pre-configured string.
+type tableSnapshot struct {
+	snap   odfdb.Snapshot
+	prefix string
+}
+
+// Has retrieves if a prefixed version of a key is present in the snapshot.
+func (s *tableSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(append([]byte(s.prefix), key...))
+}
+
+// Get retrieves the given prefixed key if it's present in the snapshot.
+func (s *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(append([]byte(s.prefix), key...))
+}
+
+// Release releases the underlying snapshot.
+func (s *tableSnapshot) Release() {
+	s.snap.Release()
+}
+
 // tableIterator is a wrapper around a database iterator that prefixes each key access
 // with a pre-configured string.
 type tableIterator struct {
@@ -0,0 +1,205 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/consensus/odfash"
+	"github.com/odf/go-odf/core"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/state"
+	"github.com/odf/go-odf/core/state/snapshot"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/crypto"
+	"github.com/odf/go-odf/odfdb"
+	"github.com/odf/go-odf/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testIdle    = common.HexToAddress("0x1d1e")
+	testStorage = common.HexToAddress("0x5707a9e")
+
+	// testStorageCode stores the first word of the call data into slot 0.
+	testStorageCode = []byte{0x60, 0x00, 0x35, 0x60, 0x00, 0x55}
+)
+
+// newTestChain creates an archive chain of the given length with snapshots enabled.
+// Every block funds a fresh account and overwrites a contract storage slot with
+// its number, so the state of all blocks is distinct and persisted on disk.
+func newTestChain(t *testing.T, n int) (odfdb.Database, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			testAddr:    {Balance: big.NewInt(1000000000000000000)},
+			testIdle:    {Balance: big.NewInt(1)},
+			testStorage: {Balance: new(big.Int), Code: testStorageCode, Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(1000))}},
+		},
+	}).MustCommit(db)
+
+	signer := types.LatestSigner(params.TestChainConfig)
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, odfash.NewFaker(), db, n, func(i int, block *core.BlockGen) {
+		nonce := block.TxNonce(testAddr)
+		fund, _ := types.SignTx(types.NewTransaction(nonce, common.BigToAddress(big.NewInt(int64(0x1000+i))), big.NewInt(1000), params.TxGas, nil, nil), signer, testKey)
+		block.AddTx(fund)
+		store, _ := types.SignTx(types.NewTransaction(nonce+1, testStorage, new(big.Int), 100000, nil, common.BigToHash(block.Number()).Bytes()), signer, testKey)
+		block.AddTx(store)
+	})
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true, SnapshotLimit: 256, SnapshotWait: true}, params.TestChainConfig, odfash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	return db, append([]*types.Block{genesis}, blocks...)
+}
+
+// trieNodes collects the hashes of all the trie nodes persisted in the database.
+func trieNodes(db odfdb.Database) map[common.Hash]struct{} {
+	nodes := make(map[common.Hash]struct{})
+
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) == common.HashLength {
+			nodes[common.BytesToHash(it.Key())] = struct{}{}
+		}
+	}
+	return nodes
+}
+
+// checkState ensures the entire state at the given root is present, with the
+// contract storage slot set to the expected value.
+func checkState(t *testing.T, db odfdb.Database, root common.Hash, slot uint64) {
+	t.Helper()
+
+	statedb, err := state.New(root, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("state %x missing: %v", root, err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("state %x incomplete: %v", root, it.Error)
+	}
+	if have, want := statedb.GetState(testStorage, common.Hash{}), common.BigToHash(new(big.Int).SetUint64(slot)); have != want {
+		t.Fatalf("storage slot mismatch in state %x: have %x, want %x", root, have, want)
+	}
+}
+
+// Tests that a pruning interrupted after committing its state bloom, with a part
+// of the stale state already deleted, is resumed and completed on the next start.
+func TestPruningRecovery(t *testing.T) {
+	db, blocks := newTestChain(t, 10)
+	target := blocks[5].Root()
+
+	datadir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	pruner, err := NewPruner(db, datadir, "", 256)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	// Run the pruning up to the bloom commit, delete a stale state root and abort
+	if err := extractGenesis(db, pruner.stateBloom); err != nil {
+		t.Fatalf("failed to extract genesis: %v", err)
+	}
+	if err := snapshot.GenerateTrie(pruner.snaptree, target, db, pruner.stateBloom); err != nil {
+		t.Fatalf("failed to regenerate target state: %v", err)
+	}
+	name := bloomFilterName(datadir, target)
+	if err := pruner.stateBloom.Commit(name, name+stateBloomFileTempSuffix); err != nil {
+		t.Fatalf("failed to commit state bloom: %v", err)
+	}
+	rawdb.DeleteTrieNode(db, blocks[8].Root())
+
+	if _, root, _ := findBloomFilter(datadir); root != target {
+		t.Fatalf("pruning marker mismatch: have %x, want %x", root, target)
+	}
+	// Resume the pruning and ensure only the genesis and target states are retained
+	if err := RecoverPruning(datadir, db, ""); err != nil {
+		t.Fatalf("failed to recover pruning: %v", err)
+	}
+	if path, _, _ := findBloomFilter(datadir); path != "" {
+		t.Fatalf("pruning marker left after recovery: %s", path)
+	}
+	checkState(t, db, blocks[0].Root(), 1000)
+	checkState(t, db, target, 5)
+
+	for i, block := range blocks {
+		if i == 0 || i == 5 {
+			continue
+		}
+		if blob := rawdb.ReadTrieNode(db, block.Root()); len(blob) != 0 {
+			t.Errorf("stale state root of block %d retained", i)
+		}
+	}
+	if root := rawdb.ReadSnapshotRoot(db); root != target {
+		t.Errorf("snapshot root mismatch: have %x, want %x", root, target)
+	}
+}
+
+// Tests that a snapshot which doesn't regenerate the target state aborts the
+// pruning before anything is deleted.
+func TestPruningCorruptedSnapshot(t *testing.T) {
+	db, blocks := newTestChain(t, 10)
+	target := blocks[5].Root()
+
+	datadir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	// Corrupt an account never touched after genesis, so it lives in the disk layer
+	rawdb.WriteAccountSnapshot(db, crypto.Keccak256Hash(testIdle.Bytes()), snapshot.SlimAccountRLP(0, big.NewInt(2), emptyRoot, emptyCode))
+
+	nodes := trieNodes(db)
+
+	pruner, err := NewPruner(db, datadir, "", 256)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.Prune(target); err == nil {
+		t.Fatalf("pruning succeeded with a corrupted snapshot")
+	}
+	if have := trieNodes(db); len(have) != len(nodes) {
+		t.Fatalf("trie nodes deleted: have %d, want %d", len(have), len(nodes))
+	}
+	if path, _, _ := findBloomFilter(datadir); path != "" {
+		t.Fatalf("pruning marker committed for a corrupted snapshot: %s", path)
+	}
+	checkState(t, db, blocks[0].Root(), 1000)
+	for i, block := range blocks[1:] {
+		checkState(t, db, block.Root(), uint64(i+1))
+	}
+}
@@ -23,6 +23,8 @@ import (
 	"time"
 
 	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/odfdb/memorydb"
 	"github.com/odf/go-odf/log"
 	"github.com/odf/go-odf/rlp"
@@ -88,6 +90,56 @@ func VerifyState(snaptree *Tree, root common.Hash) error {
 	return nil
 }
 
+// GenerateTrie takes the whole snapshot tree as the input, traverses all the
+// accounts as well as the corresponding storages and regenerate the whole state
+// (account trie + all storage tries) into the destination database. The contract
+// codes referenced by the accounts are copied over from the source database.
+func GenerateTrie(snaptree *Tree, root common.Hash, src odfdb.Database, dst odfdb.KeyValueWriter) error {
+	// Traverse all state by snapshot, re-generate the whole state trie
+	acctIt, err := snaptree.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return err // The required snapshot might not exist.
+	}
+	defer acctIt.Release()
+
+	got, err := generateTrieRoot(acctIt, common.Hash{}, stackTrieGenerate(dst), func(account common.Hash, stat *generateStats) common.Hash {
+		// Migrate the code first, copy the contract code into the destination db.
+		if blob := acctIt.Account(); blob != nil {
+			acc, err := FullAccount(blob)
+			if err != nil {
+				return common.Hash{}
+			}
+			if !bytes.Equal(acc.CodeHash, emptyCode[:]) {
+				code := rawdb.ReadCode(src, common.BytesToHash(acc.CodeHash))
+				if len(code) == 0 {
+					return common.Hash{}
+				}
+				rawdb.WriteCode(dst, common.BytesToHash(acc.CodeHash), code)
+			}
+		}
+		// Then migrate all storage trie nodes into the destination db.
+		storageIt, err := snaptree.StorageIterator(root, account, common.Hash{})
+		if err != nil {
+			return common.Hash{}
+		}
+		defer storageIt.Release()
+
+		hash, err := generateTrieRoot(storageIt, account, stackTrieGenerate(dst), nil, stat, false)
+		if err != nil {
+			return common.Hash{}
+		}
+		return hash
+	}, &generateStats{start: time.Now()}, true)
+
+	if err != nil {
+		return err
+	}
+	if got != root {
+		return fmt.Errorf("state root hash mismatch: got %x, want %x", got, root)
+	}
+	return nil
+}
+
 // generateStats is a collection of statistics gathered by the trie generator
 // for logging purposes.
 type generateStats struct {
@@ -273,3 +325,22 @@ func stdGenerate(in chan (trieKV), out chan (common.Hash)) {
 	}
 	out <- t.Hash()
 }
+
+// stackTrieGenerate returns a trie generator which builds the trie with the
+// stack trie algorithm and commits all the generated nodes into the given
+// database.
+func stackTrieGenerate(db odfdb.KeyValueWriter) trieGeneratorFn {
+	return func(in chan (trieKV), out chan (common.Hash)) {
+		t := trie.NewStackTrie(db)
+		for leaf := range in {
+			t.TryUpdate(leaf.key[:], leaf.value)
+		}
+		var root common.Hash
+		if db == nil {
+			root = t.Hash()
+		} else {
+			root, _ = t.Commit()
+		}
+		out <- root
+	}
+}
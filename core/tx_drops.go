@@ -0,0 +1,147 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/types"
+)
+
+// txDropHistoryLimit is the number of most recently dropped transactions the pool
+// remembers the drop reason of.
+const txDropHistoryLimit = 4096
+
+// TxDropReason describes why a transaction was removed from the pool without
+// being mined by it.
+type TxDropReason string
+
+const (
+	// TxDropUnderpriced is used for transactions discarded to make room for better
+	// priced ones, or priced below a raised minimum gas price.
+	TxDropUnderpriced TxDropReason = "underpriced"
+
+	// TxDropReplaced is used for transactions replaced by another one from the
+	// same sender with the same nonce and a sufficiently higher gas price.
+	TxDropReplaced TxDropReason = "replaced"
+
+	// TxDropEvicted is used for transactions removed to keep the pending or queued
+	// sections of the pool within their configured slot limits.
+	TxDropEvicted TxDropReason = "evicted"
+
+	// TxDropExpired is used for transactions queued for longer than the configured
+	// lifetime without their sender submitting anything new.
+	TxDropExpired TxDropReason = "expired"
+
+	// TxDropPrivateExpired is used for privately submitted transactions that were
+	// not mined before the expiry requested on submission.
+	TxDropPrivateExpired TxDropReason = "privateExpired"
+
+	// TxDropUnpayable is used for transactions whose cost exceeds the balance of
+	// the sender or whose gas exceeds the block gas limit.
+	TxDropUnpayable TxDropReason = "unpayable"
+)
+
+// TxDropRecord describes the removal of a transaction from the pool.
+type TxDropRecord struct {
+	Reason      TxDropReason
+	Time        time.Time
+	Replacement common.Hash // Hash of the replacing transaction, if replaced
+}
+
+// txDropEntry is a slot of the drop history ring buffer.
+type txDropEntry struct {
+	hash common.Hash
+	seq  uint64
+}
+
+// txDropHistory is a bounded record of the most recently dropped transactions. It
+// also buffers the drop events until the pool can deliver them without holding its
+// lock.
+//
+// Note, the history is not thread safe, it is guarded by the pool lock.
+type txDropHistory struct {
+	records map[common.Hash]*txDropRecordSeq
+	ring    []txDropEntry
+	next    int    // ring slot to overwrite next
+	seq     uint64 // sequence number of the last record
+	events  []DropTxsEvent
+}
+
+// txDropRecordSeq is a drop record tagged with its sequence number, which allows
+// telling apart transactions that were dropped, re-added and dropped again.
+type txDropRecordSeq struct {
+	TxDropRecord
+	seq uint64
+}
+
+// newTxDropHistory creates an empty drop history retaining at most limit records.
+func newTxDropHistory(limit int) *txDropHistory {
+	return &txDropHistory{
+		records: make(map[common.Hash]*txDropRecordSeq),
+		ring:    make([]txDropEntry, limit),
+	}
+}
+
+// add records the removal of the given transactions and queues an event for them.
+func (h *txDropHistory) add(reason TxDropReason, txs ...*types.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		h.record(tx.Hash(), TxDropRecord{Reason: reason, Time: time.Now()})
+	}
+	h.events = append(h.events, DropTxsEvent{Txs: txs, Reason: reason})
+}
+
+// replaced records the replacement of old by tx and queues an event for it.
+func (h *txDropHistory) replaced(old, tx *types.Transaction) {
+	h.record(old.Hash(), TxDropRecord{Reason: TxDropReplaced, Time: time.Now(), Replacement: tx.Hash()})
+	h.events = append(h.events, DropTxsEvent{Txs: []*types.Transaction{old}, Reason: TxDropReplaced, Replacement: tx})
+}
+
+// record stores a drop record, overwriting the oldest one if the history is full.
+func (h *txDropHistory) record(hash common.Hash, record TxDropRecord) {
+	h.seq++
+	if old := h.ring[h.next]; old.seq != 0 {
+		if r := h.records[old.hash]; r != nil && r.seq == old.seq {
+			delete(h.records, old.hash)
+		}
+	}
+	h.ring[h.next] = txDropEntry{hash: hash, seq: h.seq}
+	h.next = (h.next + 1) % len(h.ring)
+	h.records[hash] = &txDropRecordSeq{TxDropRecord: record, seq: h.seq}
+}
+
+// get returns the drop record of the given transaction, or nil if the transaction
+// was not dropped recently.
+func (h *txDropHistory) get(hash common.Hash) *TxDropRecord {
+	r := h.records[hash]
+	if r == nil {
+		return nil
+	}
+	record := r.TxDropRecord
+	return &record
+}
+
+// takeEvents returns the queued drop events and clears the queue.
+func (h *txDropHistory) takeEvents() []DropTxsEvent {
+	events := h.events
+	h.events = nil
+	return events
+}
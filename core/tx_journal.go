@@ -178,3 +178,109 @@ func (journal *txJournal) close() error {
 	}
 	return err
 }
+
+// poolJournalEntry is a transaction stored in the pool journal, flagged with
+// whodfer it originated locally.
+type poolJournalEntry struct {
+	Local bool
+	Tx    *types.Transaction
+}
+
+// poolJournal is a snapshot of the entire transaction pool, regenerated
+// periodically and on shutdown, which allows remote transactions to survive
+// node restarts too.
+type poolJournal struct {
+	path string // Filesystem path to store the snapshot at
+}
+
+// newPoolJournal creates a new pool journal stored at the given path.
+func newPoolJournal(path string) *poolJournal {
+	return &poolJournal{
+		path: path,
+	}
+}
+
+// load parses a pool snapshot from disk, injecting the local and the remote
+// transactions into the pool via the given modfods.
+func (journal *poolJournal) load(addLocals, addRemotes func([]*types.Transaction) []error) error {
+	// Skip the parsing if the snapshot doesn't exist at all
+	if _, err := os.Stat(journal.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(journal.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var (
+		stream          = rlp.NewStream(input, 0)
+		locals, remotes types.Transactions
+		failure         error
+	)
+	for {
+		entry := new(poolJournalEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		if entry.Local {
+			locals = append(locals, entry.Tx)
+		} else {
+			remotes = append(remotes, entry.Tx)
+		}
+	}
+	// Inject the transactions in small-ish batches, locals first to retain their
+	// priority. Locals are usually already known from the local journal.
+	dropped := 0
+	inject := func(txs types.Transactions, add func([]*types.Transaction) []error) {
+		for len(txs) > 0 {
+			batch := txs
+			if len(batch) > 1024 {
+				batch = batch[:1024]
+			}
+			for _, err := range add(batch) {
+				if err != nil && err != ErrAlreadyKnown {
+					log.Debug("Failed to add journaled pool transaction", "err", err)
+					dropped++
+				}
+			}
+			txs = txs[len(batch):]
+		}
+	}
+	inject(locals, addLocals)
+	inject(remotes, addRemotes)
+
+	log.Info("Loaded transaction pool journal", "locals", len(locals), "remotes", len(remotes), "dropped", dropped)
+	return failure
+}
+
+// save regenerates the pool snapshot with the given transactions.
+func (journal *poolJournal) save(locals, remotes types.Transactions) error {
+	output, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	for _, tx := range locals {
+		if err = rlp.Encode(output, &poolJournalEntry{Local: true, Tx: tx}); err != nil {
+			output.Close()
+			return err
+		}
+	}
+	for _, tx := range remotes {
+		if err = rlp.Encode(output, &poolJournalEntry{Tx: tx}); err != nil {
+			output.Close()
+			return err
+		}
+	}
+	if err = output.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+	log.Info("Regenerated transaction pool journal", "locals", len(locals), "remotes", len(remotes))
+	return nil
+}
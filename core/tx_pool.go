@@ -26,6 +26,7 @@ import (
 
 	"github.com/odf/go-odf/common"
 	"github.com/odf/go-odf/common/prque"
+	"github.com/odf/go-odf/consensus/misc"
 	"github.com/odf/go-odf/core/state"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/event"
@@ -139,6 +140,9 @@ type TxPoolConfig struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	PoolJournal      string // Snapshot of all pooled transactions to survive node restarts (disabled if empty)
+	PoolJournalSlots uint64 // Maximum number of remote transaction slots stored in the pool journal
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -156,6 +160,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	PoolJournalSlots: 4096 + 1024, // GlobalSlots + GlobalQueue
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -175,6 +181,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.PoolJournal != "" && conf.PoolJournalSlots < 1 {
+		log.Warn("Sanitizing invalid txpool pool journal slots", "provided", conf.PoolJournalSlots, "updated", DefaultTxPoolConfig.PoolJournalSlots)
+		conf.PoolJournalSlots = DefaultTxPoolConfig.PoolJournalSlots
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -219,24 +229,31 @@ type TxPool struct {
 	chain       blockChain
 	gasPrice    *big.Int
 	txFeed      event.Feed
+	privateFeed event.Feed
+	dropFeed    event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whodfer we are in the istanbul stage.
+	eip2718  bool // Fork indicator whodfer we are using EIP-2718 type transactions.
+	eip1559  bool // Fork indicator whodfer we are using EIP-1559 type transactions.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals      *accountSet  // Set of local transaction to exempt from eviction rules
+	journal     *txJournal   // Journal of local transaction to back up to disk
+	poolJournal *poolJournal // Snapshot of the entire pool to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
+	drops   *txDropHistory               // Recently dropped transactions and their reasons
+	private map[common.Hash]time.Time    // Privately submitted transactions and their expiry
 
 	chainHeadCh     chan ChainHeadEvent
 	chainHeadSub    event.Subscription
@@ -263,11 +280,13 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:          config,
 		chainconfig:     chainconfig,
 		chain:           chain,
-		signer:          types.NewEIP155Signer(chainconfig.ChainID),
+		signer:          types.LatestSigner(chainconfig),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
 		all:             newTxLookup(),
+		drops:           newTxDropHistory(txDropHistoryLimit),
+		private:         make(map[common.Hash]time.Time),
 		chainHeadCh:     make(chan ChainHeadEvent, chainHeadChanSize),
 		reqResetCh:      make(chan *txpoolResetRequest),
 		reqPromoteCh:    make(chan *accountSet),
@@ -299,6 +318,18 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If the pool journal is enabled, reload the remote transactions too
+	if config.PoolJournal != "" {
+		pool.poolJournal = newPoolJournal(config.PoolJournal)
+
+		addLocals := pool.AddLocals
+		if config.NoLocals {
+			addLocals = pool.AddRemotes
+		}
+		if err := pool.poolJournal.load(addLocals, pool.AddRemotes); err != nil {
+			log.Warn("Failed to load transaction pool journal", "err", err)
+		}
+	}
 
 	// Subscribe events from blockchain and start the main event loop.
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
@@ -367,11 +398,17 @@ func (pool *TxPool) loop() {
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true)
 					}
+					pool.drops.add(TxDropExpired, list...)
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
+			// Drop any private transactions not mined in time
+			pool.expirePrivate(time.Now())
+			drops := pool.drops.takeEvents()
 			pool.mu.Unlock()
 
+			pool.sendDropEvents(drops)
+
 		// Handle local transaction journal rotation
 		case <-journal.C:
 			if pool.journal != nil {
@@ -381,6 +418,7 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			pool.savePoolJournal()
 		}
 	}
 }
@@ -397,6 +435,7 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	pool.savePoolJournal()
 	log.Info("Transaction pool stopped")
 }
 
@@ -406,6 +445,27 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribePrivateTxsEvent registers a subscription of NewTxsEvent for the
+// privately submitted transactions, which are never sent to the subscribers
+// of SubscribeNewTxsEvent.
+func (pool *TxPool) SubscribePrivateTxsEvent(ch chan<- NewTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.privateFeed.Subscribe(ch))
+}
+
+// SubscribeDropTxsEvent registers a subscription of DropTxsEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeDropTxsEvent(ch chan<- DropTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// sendDropEvents delivers drop events taken from the history to the subscribers.
+// It must be called without holding the pool lock.
+func (pool *TxPool) sendDropEvents(events []DropTxsEvent) {
+	for _, ev := range events {
+		pool.dropFeed.Send(ev)
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -418,12 +478,17 @@ func (pool *TxPool) GasPrice() *big.Int {
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
 	pool.mu.Lock()
-	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
-	for _, tx := range pool.priced.Cap(price, pool.locals) {
+	drop := pool.priced.Cap(price, pool.locals)
+	for _, tx := range drop {
 		pool.removeTx(tx.Hash(), false)
 	}
+	pool.drops.add(TxDropUnderpriced, drop...)
+	drops := pool.drops.takeEvents()
+	pool.mu.Unlock()
+
+	pool.sendDropEvents(drops)
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
@@ -461,17 +526,22 @@ func (pool *TxPool) stats() (int, int) {
 
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
+// Privately submitted transactions are omitted.
 func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
 	pending := make(map[common.Address]types.Transactions)
 	for addr, list := range pool.pending {
-		pending[addr] = list.Flatten()
+		if txs := pool.public(list.Flatten()); len(txs) > 0 {
+			pending[addr] = txs
+		}
 	}
 	queued := make(map[common.Address]types.Transactions)
 	for addr, list := range pool.queue {
-		queued[addr] = list.Flatten()
+		if txs := pool.public(list.Flatten()); len(txs) > 0 {
+			queued[addr] = txs
+		}
 	}
 	return pending, queued
 }
@@ -505,18 +575,105 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	txs := make(map[common.Address]types.Transactions)
 	for addr := range pool.locals.accounts {
 		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(pending.Flatten())...)
 		}
 		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+			txs[addr] = append(txs[addr], pool.public(queued.Flatten())...)
 		}
 	}
 	return txs
 }
 
+// public filters out the privately submitted transactions, which must be kept
+// out of the journals and the public views of the pool.
+//
+// Note, this modfod assumes the pool lock is held!
+func (pool *TxPool) public(txs types.Transactions) types.Transactions {
+	if len(pool.private) == 0 {
+		return txs
+	}
+	filtered := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if !pool.isPrivate(tx.Hash()) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
+
+// savePoolJournal regenerates the pool journal, if enabled, with the current
+// contents of the pool.
+func (pool *TxPool) savePoolJournal() {
+	if pool.poolJournal == nil {
+		return
+	}
+	pool.mu.RLock()
+	locals, remotes := pool.journaled()
+	pool.mu.RUnlock()
+
+	if err := pool.poolJournal.save(locals, remotes); err != nil {
+		log.Warn("Failed to save transaction pool journal", "err", err)
+	}
+}
+
+// journaled retrieves the transactions to store in the pool journal: all the
+// local ones and, up to the configured slot limit, the remote ones. Both the
+// pending and the queued transactions of every remote account are considered,
+// picking the best priced ones across accounts first, in nonce order.
+//
+// Note, this modfod assumes the pool lock is held!
+func (pool *TxPool) journaled() (types.Transactions, types.Transactions) {
+	var locals types.Transactions
+	for _, txs := range pool.local() {
+		locals = append(locals, txs...)
+	}
+	// Gather the public transactions of every remote account, pending and queued
+	sequences := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			sequences[addr] = append(sequences[addr], pool.public(list.Flatten())...)
+		}
+	}
+	for addr, list := range pool.queue {
+		if !pool.locals.contains(addr) {
+			sequences[addr] = append(sequences[addr], pool.public(list.Flatten())...)
+		}
+	}
+	for addr, txs := range sequences {
+		if len(txs) == 0 {
+			delete(sequences, addr)
+		}
+	}
+	// Pick transactions by price while honouring the nonce order, dropping the rest
+	// of an account's sequence once a transaction doesn't fit
+	var (
+		remotes types.Transactions
+		slots   uint64
+		txs     = types.NewTransactionsByPriceAndNonce(pool.signer, sequences, nil)
+	)
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		if slots+uint64(numSlots(tx)) > pool.config.PoolJournalSlots {
+			txs.Pop()
+			continue
+		}
+		slots += uint64(numSlots(tx))
+		remotes = append(remotes, tx)
+		txs.Shift()
+	}
+	return locals, remotes
+}
+
 // validateTx checks whodfer a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Accept only legacy transactions until EIP-2718/2930 activates.
+	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+	// Reject dynamic fee transactions until EIP-1559 activates.
+	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
+		return ErrTxTypeNotSupported
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
@@ -530,14 +687,25 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentMaxGas < tx.Gas() {
 		return ErrGasLimit
 	}
+	// Sanity check for extremely large numbers
+	if tx.GasFeeCap().BitLen() > 256 {
+		return ErrFeeCapVeryHigh
+	}
+	if tx.GasTipCap().BitLen() > 256 {
+		return ErrTipVeryHigh
+	}
+	// Ensure gasFeeCap is greater than or equal to gasTipCap.
+	if tx.GasFeeCapIntCmp(tx.GasTipCap()) < 0 {
+		return ErrTipAboveFeeCap
+	}
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price
+	// Drop non-local transactions under our own minimal accepted gas price or tip
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && tx.GasPriceIntCmp(pool.gasPrice) < 0 {
+	if !local && tx.GasTipCapIntCmp(pool.gasPrice) < 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
@@ -550,7 +718,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -596,6 +764,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			underpricedTxMeter.Mark(1)
 			pool.removeTx(tx.Hash(), false)
 		}
+		pool.drops.add(TxDropUnderpriced, drop...)
 	}
 	// Try to replace an existing transaction in the pending pool
 	from, _ := types.Sender(pool.signer, tx) // already validated
@@ -610,6 +779,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
+			pool.drops.replaced(old, tx)
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all.Add(tx)
@@ -662,6 +832,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.drops.replaced(old, tx)
 		queuedReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the queued counter
@@ -681,8 +852,8 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
-	if pool.journal == nil || !pool.locals.contains(from) {
+	// Only journal if it's enabled and the transaction is local (but not private)
+	if pool.journal == nil || !pool.locals.contains(from) || pool.isPrivate(tx.Hash()) {
 		return
 	}
 	if err := pool.journal.insert(tx); err != nil {
@@ -706,6 +877,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
+		pool.drops.replaced(tx, list.txs.Get(tx.Nonce()))
 		pendingDiscardMeter.Mark(1)
 		return false
 	}
@@ -713,6 +885,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
+		pool.drops.replaced(old, tx)
 		pendingReplaceMeter.Mark(1)
 	} else {
 		// Nothing was replaced, bump the pending counter
@@ -740,6 +913,76 @@ func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
 	return pool.addTxs(txs, !pool.config.NoLocals, true)
 }
 
+// AddPrivate enqueues a transaction which is only to be included by the local
+// miner and never announced or propagated to remote peers. If it's not mined
+// by the given expiry, it is dropped from the pool.
+//
+// The transaction is validated like a remote one and the modfod waits for pool
+// reorganization. Resubmitting a private transaction updates its expiry.
+func (pool *TxPool) AddPrivate(tx *types.Transaction, expiry time.Time) error {
+	// Mark the transaction private before it can be announced to subsystems
+	hash := tx.Hash()
+
+	pool.mu.Lock()
+	prev, known := pool.private[hash]
+	if !known && pool.all.Get(hash) != nil {
+		pool.mu.Unlock()
+		return ErrAlreadyKnown // already public, too late to keep it private
+	}
+	pool.private[hash] = expiry
+	pool.mu.Unlock()
+
+	err := pool.addTxs([]*types.Transaction{tx}, false, true)[0]
+	if known && err == ErrAlreadyKnown {
+		return nil
+	}
+	if err != nil {
+		pool.mu.Lock()
+		if known {
+			pool.private[hash] = prev
+		} else {
+			delete(pool.private, hash)
+		}
+		pool.mu.Unlock()
+	}
+	return err
+}
+
+// IsPrivate returns whodfer the transaction with the given hash was submitted
+// privately and thus must not be propagated to the network.
+func (pool *TxPool) IsPrivate(hash common.Hash) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.isPrivate(hash)
+}
+
+// isPrivate is the lockless version of IsPrivate.
+func (pool *TxPool) isPrivate(hash common.Hash) bool {
+	_, ok := pool.private[hash]
+	return ok
+}
+
+// expirePrivate drops the private transactions which weren't mined before their
+// expiry. Private markers are retained until then even if the transaction left the
+// pool, so a transaction reinjected by a reorg stays private.
+//
+// Note, this modfod assumes the pool lock is held!
+func (pool *TxPool) expirePrivate(now time.Time) {
+	var expired types.Transactions
+	for hash, expiry := range pool.private {
+		if now.Before(expiry) {
+			continue
+		}
+		if tx := pool.all.Get(hash); tx != nil {
+			pool.removeTx(hash, true)
+			expired = append(expired, tx)
+		}
+		delete(pool.private, hash)
+	}
+	pool.drops.add(TxDropPrivateExpired, expired...)
+}
+
 // AddLocal enqueues a single local transaction into the pool if it is valid. This is
 // a convenience wrapper aroundd AddLocals.
 func (pool *TxPool) AddLocal(tx *types.Transaction) error {
@@ -866,6 +1109,15 @@ func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
 	return status
 }
 
+// Dropped returns the reason the given transaction was removed from the pool, or
+// nil if it is unknown or was dropped too long ago to be remembered.
+func (pool *TxPool) Dropped(hash common.Hash) *TxDropRecord {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.drops.get(hash)
+}
+
 // Get returns a transaction if it is contained in the pool and nil otherwise.
 func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all.Get(hash)
@@ -1065,6 +1317,10 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
 		pool.demoteUnexecutables()
+		if reset.newHead != nil && pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
+			pendingBaseFee := misc.CalcBaseFee(pool.chainconfig, reset.newHead)
+			pool.priced.SetBaseFee(pendingBaseFee)
+		}
 	}
 	// Ensure pool.queue and pool.pending sizes stay within the configured limits.
 	pool.truncatePending()
@@ -1075,6 +1331,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		highestPending := list.LastElement()
 		pool.pendingNonces.set(addr, highestPending.Nonce()+1)
 	}
+	drops := pool.drops.takeEvents()
 	pool.mu.Unlock()
 
 	// Notify subsystems for newly added transactions
@@ -1086,12 +1343,26 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		events[addr].Put(tx)
 	}
 	if len(events) > 0 {
-		var txs []*types.Transaction
+		var txs, private []*types.Transaction
+		pool.mu.RLock()
 		for _, set := range events {
-			txs = append(txs, set.Flatten()...)
+			for _, tx := range set.Flatten() {
+				if pool.isPrivate(tx.Hash()) {
+					private = append(private, tx)
+				} else {
+					txs = append(txs, tx)
+				}
+			}
+		}
+		pool.mu.RUnlock()
+		if len(txs) > 0 {
+			pool.txFeed.Send(NewTxsEvent{txs})
+		}
+		if len(private) > 0 {
+			pool.privateFeed.Send(NewTxsEvent{private})
 		}
-		pool.txFeed.Send(NewTxsEvent{txs})
 	}
+	pool.sendDropEvents(drops)
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
@@ -1180,6 +1451,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.eip2718 = pool.chainconfig.IsBerlin(next)
+	pool.eip1559 = pool.chainconfig.IsLondon(next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -1208,6 +1481,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.drops.add(TxDropUnpayable, drops...)
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1231,6 +1505,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.drops.add(TxDropEvicted, caps...)
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1297,6 +1572,7 @@ func (pool *TxPool) truncatePending() {
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
 					pool.priced.Removed(len(caps))
+					pool.drops.add(TxDropEvicted, caps...)
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
 						localGauge.Dec(int64(len(caps)))
@@ -1324,6 +1600,7 @@ func (pool *TxPool) truncatePending() {
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
 				pool.priced.Removed(len(caps))
+				pool.drops.add(TxDropEvicted, caps...)
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
 					localGauge.Dec(int64(len(caps)))
@@ -1363,9 +1640,11 @@ func (pool *TxPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true)
 			}
+			pool.drops.add(TxDropEvicted, txs...)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
 			continue
@@ -1374,6 +1653,7 @@ func (pool *TxPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.drops.add(TxDropEvicted, txs[i])
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1403,6 +1683,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 		}
 		pool.priced.Removed(len(olds) + len(drops))
+		pool.drops.add(TxDropUnpayable, drops...)
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -1849,6 +1850,127 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that the pool journal snapshots remote transactions along with the local
+// ones, within its slot limit, and that they are reloaded after a restart.
+func TestTransactionPoolJournaling(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pooljournal")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PoolJournal = filepath.Join(dir, "pool.rlp")
+	config.PoolJournalSlots = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	queued, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{local, remote, queued} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	// Add a local, two executable and a better priced queued remote transactions,
+	// the last executable one exceeding the slot limit of the journal
+	var (
+		localTx   = pricedTransaction(0, 100000, big.NewInt(1), local)
+		remoteTxs = []*types.Transaction{
+			pricedTransaction(0, 100000, big.NewInt(1), remote),
+			pricedTransaction(1, 100000, big.NewInt(1), remote),
+		}
+		queuedTx = pricedTransaction(5, 100000, big.NewInt(2), queued)
+	)
+	if err := pool.AddLocal(localTx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for _, err := range pool.AddRemotesSync(append(remoteTxs, queuedTx)) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction: %v", err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool contents mismatch: have %d pending %d queued, want 3 pending 1 queued", pending, queued)
+	}
+	// Restart the pool and ensure the journaled transactions survive
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	for i, tx := range []*types.Transaction{localTx, queuedTx, remoteTxs[0]} {
+		if !pool.Has(tx.Hash()) {
+			t.Errorf("transaction %d missing after restart", i)
+		}
+	}
+	if pool.Has(remoteTxs[1].Hash()) {
+		t.Errorf("transaction over the journal limit restored")
+	}
+	pool.mu.RLock()
+	if list := pool.queue[crypto.PubkeyToAddress(queued.PublicKey)]; list == nil || list.txs.Get(queuedTx.Nonce()) == nil {
+		t.Errorf("queued only remote account not restored into the queue")
+	}
+	pool.mu.RUnlock()
+	if !pool.locals.contains(crypto.PubkeyToAddress(local.PublicKey)) {
+		t.Errorf("local account not restored as local")
+	}
+	if pool.locals.contains(crypto.PubkeyToAddress(remote.PublicKey)) {
+		t.Errorf("remote account restored as local")
+	}
+}
+
+// Tests that the pool journal picks the pending and queued remote transactions by
+// price across accounts, in nonce order.
+func TestTransactionPoolJournalSelection(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	cheap, _ := crypto.GenerateKey()
+	pricey, _ := crypto.GenerateKey()
+	queued, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{cheap, pricey, queued} {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), cheap),
+		pricedTransaction(1, 100000, big.NewInt(1), cheap),
+		pricedTransaction(3, 100000, big.NewInt(10), cheap), // gapped, journaled after its predecessors
+		pricedTransaction(0, 100000, big.NewInt(5), pricey),
+		pricedTransaction(1, 100000, big.NewInt(3), pricey),
+		pricedTransaction(2, 100000, big.NewInt(4), queued), // queued only account
+	}
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	check := func(limit uint64, want []*types.Transaction) {
+		t.Helper()
+
+		pool.mu.Lock()
+		pool.config.PoolJournalSlots = limit
+		_, remotes := pool.journaled()
+		pool.mu.Unlock()
+
+		if len(remotes) != len(want) {
+			t.Fatalf("journaled transaction count mismatch: have %d, want %d", len(remotes), len(want))
+		}
+		for i, tx := range remotes {
+			if tx.Hash() != want[i].Hash() {
+				t.Errorf("journaled transaction %d mismatch: have nonce %d price %v, want nonce %d price %v", i, tx.Nonce(), tx.GasPrice(), want[i].Nonce(), want[i].GasPrice())
+			}
+		}
+	}
+	check(3, []*types.Transaction{txs[3], txs[5], txs[4]})
+	check(10, []*types.Transaction{txs[3], txs[5], txs[4], txs[0], txs[1], txs[2]})
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -2007,3 +2129,202 @@ func benchmarkPoolBatchInsert(b *testing.B, size int, local bool) {
 		}
 	}
 }
+
+// Tests that transactions leaving the pool without being mined are announced with
+// the reason of their removal, and that the reason is retained for lookups.
+func TestTransactionDropEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	drops := make(chan DropTxsEvent, 16)
+	sub := pool.SubscribeDropTxsEvent(drops)
+	defer sub.Unsubscribe()
+
+	expect := func(reason TxDropReason, tx *types.Transaction, replacement *types.Transaction) {
+		t.Helper()
+		select {
+		case ev := <-drops:
+			if ev.Reason != reason || len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() {
+				t.Fatalf("drop event mismatch: have %s %d txs, want %s for %x", ev.Reason, len(ev.Txs), reason, tx.Hash())
+			}
+			if replacement != nil && (ev.Replacement == nil || ev.Replacement.Hash() != replacement.Hash()) {
+				t.Fatalf("replacement mismatch: have %v, want %x", ev.Replacement, replacement.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no drop event for %x", tx.Hash())
+		}
+		record := pool.Dropped(tx.Hash())
+		if record == nil || record.Reason != reason {
+			t.Fatalf("drop record mismatch: have %v, want reason %s", record, reason)
+		}
+		if replacement != nil && record.Replacement != replacement.Hash() {
+			t.Fatalf("recorded replacement mismatch: have %x, want %x", record.Replacement, replacement.Hash())
+		}
+	}
+	// Replace a pending transaction with a better priced one
+	tx0 := pricedTransaction(0, 100000, big.NewInt(1), key)
+	tx1 := pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.addRemoteSync(tx0); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(tx1); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	expect(TxDropReplaced, tx0, tx1)
+
+	// Raise the price threshold above the replacement
+	pool.SetGasPrice(big.NewInt(10))
+	expect(TxDropUnderpriced, tx1, nil)
+
+	// Transactions whose nonce got used up by the chain must not be recorded
+	tx2 := pricedTransaction(0, 100000, big.NewInt(10), key)
+	if err := pool.addRemoteSync(tx2); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	pool.mu.Lock()
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	pool.demoteUnexecutables()
+	pool.mu.Unlock()
+
+	if pool.Has(tx2.Hash()) {
+		t.Fatalf("stale transaction retained")
+	}
+	if record := pool.Dropped(tx2.Hash()); record != nil {
+		t.Fatalf("stale transaction recorded as dropped: %v", record)
+	}
+	select {
+	case ev := <-drops:
+		t.Fatalf("stale transaction announced as dropped: %s %d txs", ev.Reason, len(ev.Txs))
+	case <-time.After(100 * time.Millisecond):
+	}
+	if record := pool.Dropped(common.Hash{0x01}); record != nil {
+		t.Fatalf("unexpected drop record for unknown transaction: %v", record)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the drop history only retains the most recent records.
+func TestTransactionDropHistoryLimit(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	history := newTxDropHistory(2)
+
+	txs := []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key)}
+	for _, tx := range txs {
+		history.add(TxDropEvicted, tx)
+	}
+	if record := history.get(txs[0].Hash()); record != nil {
+		t.Errorf("oldest record retained: %v", record)
+	}
+	for i, tx := range txs[1:] {
+		if record := history.get(tx.Hash()); record == nil || record.Reason != TxDropEvicted {
+			t.Errorf("record %d mismatch: have %v", i+1, record)
+		}
+	}
+	// Dropping a transaction again must survive the expiry of its earlier record
+	history.add(TxDropExpired, txs[1])
+	history.add(TxDropUnpayable, txs[2])
+	if record := history.get(txs[1].Hash()); record == nil || record.Reason != TxDropExpired {
+		t.Errorf("re-dropped record mismatch: have %v", record)
+	}
+	if events := history.takeEvents(); len(events) != 5 {
+		t.Errorf("queued event count mismatch: have %d, want 5", len(events))
+	}
+}
+
+// Tests that privately submitted transactions are announced to local subsystems
+// like any other, are kept out of the journals and are dropped upon expiry.
+func TestTransactionPrivate(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "privatetx")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PoolJournal = filepath.Join(dir, "pool.rlp")
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	events := make(chan NewTxsEvent, 4)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	privateEvents := make(chan NewTxsEvent, 4)
+	privateSub := pool.SubscribePrivateTxsEvent(privateEvents)
+	defer privateSub.Unsubscribe()
+
+	// Add a private transaction and ensure it's pending and only announced locally
+	private := pricedTransaction(0, 100000, big.NewInt(1), key)
+	expiry := time.Now().Add(time.Hour)
+	if err := pool.AddPrivate(private, expiry); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if !pool.IsPrivate(private.Hash()) {
+		t.Fatalf("transaction not marked private")
+	}
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if err := validateEvents(privateEvents, 1); err != nil {
+		t.Fatalf("private event firing failed: %v", err)
+	}
+	if err := validateEvents(events, 0); err != nil {
+		t.Fatalf("public event firing failed: %v", err)
+	}
+	if content, _ := pool.Content(); len(content) != 0 {
+		t.Fatalf("private transaction listed in pool content: %v", content)
+	}
+	// Resubmission extends the expiry, public transactions can't turn private
+	if err := pool.AddPrivate(private, expiry.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to resubmit private transaction: %v", err)
+	}
+	public := pricedTransaction(1, 100000, big.NewInt(1), key)
+	if err := pool.addRemoteSync(public); err != nil {
+		t.Fatalf("failed to add public transaction: %v", err)
+	}
+	if err := pool.AddPrivate(public, expiry); err != ErrAlreadyKnown {
+		t.Fatalf("public transaction privatization error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if pool.IsPrivate(public.Hash()) {
+		t.Fatalf("public transaction marked private")
+	}
+	// Ensure private transactions are not journaled, only the public ones
+	pool.mu.RLock()
+	_, remotes := pool.journaled()
+	pool.mu.RUnlock()
+	if len(remotes) != 1 || remotes[0].Hash() != public.Hash() {
+		t.Fatalf("journaled transactions mismatch: have %d, want only the public one", len(remotes))
+	}
+	// Expire the private transaction and ensure it's dropped
+	pool.mu.Lock()
+	pool.expirePrivate(expiry.Add(time.Minute))
+	if pool.all.Get(private.Hash()) == nil {
+		t.Fatalf("private transaction dropped before its extended expiry")
+	}
+	pool.expirePrivate(expiry.Add(2 * time.Hour))
+	pool.mu.Unlock()
+
+	if pool.Has(private.Hash()) || pool.IsPrivate(private.Hash()) {
+		t.Fatalf("expired private transaction retained")
+	}
+	if record := pool.Dropped(private.Hash()); record == nil || record.Reason != TxDropPrivateExpired {
+		t.Fatalf("drop record mismatch: have %v", record)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/params"
+	"github.com/holiman/uint256"
+)
+
+func TestAccessListTracerCapture(t *testing.T) {
+	var (
+		env      = NewEVM(Context{}, &dummyStatedb{}, params.TestChainConfig, Config{})
+		from     = common.HexToAddress("0x01")
+		to       = common.HexToAddress("0x02")
+		other    = common.HexToAddress("0x03")
+		tracer   = NewAccessListTracer(nil, from, to, []common.Address{common.BytesToAddress([]byte{1})})
+		mem      = NewMemory()
+		rstack   = newReturnStack()
+		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 0)
+	)
+	// Storage access records the slot of the executing contract
+	stack := newstack()
+	stack.push(uint256.NewInt().SetUint64(7))
+	tracer.CaptureState(env, 0, SLOAD, 0, 0, mem, stack, rstack, nil, contract, 0, nil)
+
+	// Account access on excluded addresses must not show up
+	for _, addr := range []common.Address{from, to, other} {
+		stack := newstack()
+		stack.push(new(uint256.Int).SetBytes(addr.Bytes()))
+		tracer.CaptureState(env, 0, BALANCE, 0, 0, mem, stack, rstack, nil, contract, 0, nil)
+	}
+	acl := tracer.AccessList()
+	if len(acl) != 2 {
+		t.Fatalf("access list length mismatch: have %d, want 2", len(acl))
+	}
+	if acl.StorageKeys() != 1 {
+		t.Fatalf("storage key count mismatch: have %d, want 1", acl.StorageKeys())
+	}
+	// A tracer seeded with the produced list must be considered equal
+	if again := NewAccessListTracer(acl, from, to, nil); !again.Equal(tracer) {
+		t.Fatalf("seeded tracer differs from original: %v", again.AccessList())
+	}
+	if empty := NewAccessListTracer(nil, from, to, nil); empty.Equal(tracer) {
+		t.Fatalf("empty tracer considered equal to populated one")
+	}
+}
@@ -19,12 +19,21 @@ package graphql
 import (
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
+	"github.com/odf/go-odf/consensus/odfash"
+	"github.com/odf/go-odf/core"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/crypto"
 	"github.com/odf/go-odf/odf"
 	"github.com/odf/go-odf/node"
+	"github.com/odf/go-odf/params"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -90,6 +99,83 @@ func TestGraphQLHTTPOnSamePort_GQLRequest_Unsuccessful(t *testing.T) {
 	assert.Equal(t, "404 page not found\n", string(bodyBytes))
 }
 
+// Tests that the receipts of a block are resolved in transaction order, with
+// the effective gas price taking the London base fee into account.
+func TestGraphQLBlockReceipts(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.AllEthashProtocolChanges
+	config.LondonBlock = big.NewInt(0)
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{
+			Config: &config,
+			Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+	)
+	blocks, _ := core.GenerateChain(&config, genesis.ToBlock(db), odfash.NewFaker(), db, 2, func(i int, g *core.BlockGen) {
+		if i != 0 {
+			return
+		}
+		g.AddTx(types.MustSignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    0,
+			GasPrice: big.NewInt(2 * params.GWei),
+			Gas:      params.TxGas,
+			To:       &common.Address{0xaa},
+		}))
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     1,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(5 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &common.Address{0xbb},
+		}))
+	})
+	stack := createNode(t, false)
+	defer stack.Close()
+
+	odfConf := &odf.Config{Genesis: genesis}
+	odfConf.Ethash.PowMode = odfash.ModeFake
+	odfBackend, err := odf.New(stack, odfConf)
+	if err != nil {
+		t.Fatalf("could not create odf backend: %v", err)
+	}
+	if err := New(stack, odfBackend.APIBackend, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	if _, err := odfBackend.BlockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+	query := func(number int) string {
+		body := strings.NewReader(fmt.Sprintf(`{"query": "{block(number:%d){receipts{status effectiveGasPrice transaction{index}}}}","variables": null}`, number))
+		gqlReq, err := http.NewRequest(http.ModfodGet, fmt.Sprintf("http://%s/graphql", "127.0.0.1:9393"), body)
+		if err != nil {
+			t.Fatalf("could not issue new http request: %v", err)
+		}
+		gqlReq.Header.Set("Content-Type", "application/json")
+		resp := doHTTPRequest(t, gqlReq)
+		defer resp.Body.Close()
+
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("could not read from response body: %v", err)
+		}
+		return string(bodyBytes)
+	}
+	price := new(big.Int).Add(blocks[0].BaseFee(), big.NewInt(params.GWei))
+	expected := fmt.Sprintf(`{"data":{"block":{"receipts":[{"status":"0x1","effectiveGasPrice":"%s","transaction":{"index":0}},{"status":"0x1","effectiveGasPrice":"%s","transaction":{"index":1}}]}}}`,
+		hexutil.EncodeBig(big.NewInt(2*params.GWei)), hexutil.EncodeBig(price))
+	assert.Equal(t, expected, query(1))
+	assert.Equal(t, `{"data":{"block":{"receipts":[]}}}`, query(2))
+}
+
 func createNode(t *testing.T, gqlEnabled bool) *node.Node {
 	stack, err := node.New(&node.Config{
 		HTTPHost: "127.0.0.1",
@@ -120,6 +120,33 @@ const schema string = `
         v: BigInt!
     }
 
+    # Receipt is the result of executing a transaction that has been mined
+    # in a block.
+    type Receipt {
+        # Transaction is the transaction this receipt belongs to.
+        transaction: Transaction!
+        # Status is the return status of the transaction. This will be 1 if the
+        # transaction succeeded, or 0 if it failed. Receipts of transactions mined
+        # before Byzantium carry an intermediate state root instead of a status,
+        # in which case this field will be null.
+        status: Long
+        # GasUsed is the amount of gas that was used processing the transaction.
+        gasUsed: Long!
+        # CumulativeGasUsed is the total gas used in the block up to and including
+        # the transaction.
+        cumulativeGasUsed: Long!
+        # EffectiveGasPrice is the price per unit of gas actually paid by the
+        # sender, taking the base fee of the block into account.
+        effectiveGasPrice: BigInt!
+        # CreatedContract is the account that was created by a contract creation
+        # transaction, or null otherwise.
+        createdContract(block: Long): Account
+        # Logs is a list of log entries emitted by the transaction.
+        logs: [Log!]!
+        # LogsBloom is the bloom filter over the logs of the transaction.
+        logsBloom: Bytes!
+    }
+
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
     # to a single block.
     input BlockFilterCriteria {
@@ -200,6 +227,10 @@ const schema string = `
         # transactions are unavailable for this block, or if the index is out of
         # bounds, this field will be null.
         transactionAt(index: Int!): Transaction
+        # Receipts is a list of the receipts of all transactions in this block,
+        # in the same order as the transactions. If the receipts are unavailable
+        # for this block, this field will be null.
+        receipts: [Receipt!]
         # Logs returns a filtered set of logs from this block.
         logs(filter: BlockFilterCriteria!): [Log!]!
         # Account fetches an Ethereum account at the current block's state.
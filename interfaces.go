@@ -119,6 +119,11 @@ type CallMsg struct {
 	GasPrice *big.Int        // wei <-> gas exchange ratio
 	Value    *big.Int        // amount of wei sent along with the call
 	Data     []byte          // input data, usually an ABI-encoded contract modfod invocation
+
+	GasFeeCap *big.Int // EIP-1559 fee cap per gas.
+	GasTipCap *big.Int // EIP-1559 tip per gas.
+
+	AccessList types.AccessList // EIP-2930 access list.
 }
 
 // A ContractCaller provides contract calls, essentially transactions that are executed by
@@ -150,6 +155,14 @@ type FilterQuery struct {
 	Topics [][]common.Hash
 }
 
+// PendingTransactionQuery contains options for filtering transactions entering the
+// transaction pool. Empty fields match any transaction.
+type PendingTransactionQuery struct {
+	From        []common.Address // restricts matches to transactions sent by these accounts
+	To          []common.Address // restricts matches to transactions sent to these accounts
+	MinGasPrice *big.Int         // restricts matches to transactions paying at least this gas price
+}
+
 // LogFilterer provides access to contract log events using a one-off query or continuous
 // event subscription.
 //
@@ -178,6 +191,15 @@ type GasPricer interface {
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 }
 
+// FeeHistory provides recent fee market data that consumers can use to determine
+// a reasonable maxPriorityFeePerGas value.
+type FeeHistory struct {
+	OldestBlock  *big.Int     // block corresponding to first response value
+	Reward       [][]*big.Int // list every txs priority fee per block
+	BaseFee      []*big.Int   // list of each block's base fee
+	GasUsedRatio []float64    // ratio of gas used out of the total available limit
+}
+
 // A PendingStateReader provides access to the pending state, which is the result of all
 // known executable transactions which have not yet been included in the blockchain. It is
 // commonly used to display the result of ’unconfirmed’ actions (e.g. wallet value
@@ -0,0 +1,158 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package odfapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
+	"github.com/odf/go-odf/consensus/odfash"
+	"github.com/odf/go-odf/core"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/crypto"
+	"github.com/odf/go-odf/params"
+	"github.com/odf/go-odf/rpc"
+)
+
+// receiptBackend is a chain backend serving a pre-generated chain. Any method
+// not overridden panics via the nil embedded Backend.
+type receiptBackend struct {
+	Backend
+
+	config   *params.ChainConfig
+	blocks   []*types.Block
+	receipts []types.Receipts
+	lookups  int
+}
+
+func (b *receiptBackend) ChainConfig() *params.ChainConfig { return b.config }
+
+func (b *receiptBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	b.lookups++
+	if number, ok := blockNrOrHash.Number(); ok {
+		if number < 0 || int(number) >= len(b.blocks) {
+			return nil, nil
+		}
+		return b.blocks[number], nil
+	}
+	hash, _ := blockNrOrHash.Hash()
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *receiptBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	for i, block := range b.blocks {
+		if block.Hash() == hash {
+			return b.receipts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Tests that block receipts are returned in transaction order with the correct
+// effective gas price, that empty blocks yield an empty list and that the
+// pending block is rejected without touching the chain.
+func TestGetBlockReceipts(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.AllEthashProtocolChanges
+	config.LondonBlock = big.NewInt(0)
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{
+			Config: &config,
+			Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		gblock = genesis.MustCommit(db)
+		signer = types.LatestSigner(&config)
+	)
+	blocks, receipts := core.GenerateChain(&config, gblock, odfash.NewFaker(), db, 2, func(i int, g *core.BlockGen) {
+		if i != 0 {
+			return
+		}
+		g.AddTx(types.MustSignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    0,
+			GasPrice: big.NewInt(2 * params.GWei),
+			Gas:      params.TxGas,
+			To:       &common.Address{0xaa},
+		}))
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     1,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(5 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &common.Address{0xbb},
+		}))
+	})
+	backend := &receiptBackend{
+		config:   &config,
+		blocks:   append([]*types.Block{gblock}, blocks...),
+		receipts: append([]types.Receipts{nil}, receipts...),
+	}
+	api := NewPublicBlockChainAPI(backend)
+
+	// Check the populated block's receipts and effective gas prices
+	result, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(1))
+	if err != nil {
+		t.Fatalf("failed to retrieve receipts: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(result), 2)
+	}
+	baseFee := blocks[0].BaseFee()
+	for i, want := range []*big.Int{
+		big.NewInt(2 * params.GWei),
+		new(big.Int).Add(baseFee, big.NewInt(params.GWei)),
+	} {
+		if have := result[i]["transactionHash"]; have != blocks[0].Transactions()[i].Hash() {
+			t.Errorf("receipt %d: tx hash mismatch: have %v, want %x", i, have, blocks[0].Transactions()[i].Hash())
+		}
+		if have := result[i]["effectiveGasPrice"].(*hexutil.Big); have.ToInt().Cmp(want) != 0 {
+			t.Errorf("receipt %d: effective gas price mismatch: have %v, want %v", i, have, want)
+		}
+		if have := result[i]["status"]; have != hexutil.Uint(types.ReceiptStatusSuccessful) {
+			t.Errorf("receipt %d: status mismatch: have %v, want %v", i, have, types.ReceiptStatusSuccessful)
+		}
+	}
+	// Empty blocks must yield an empty list, not null
+	result, err = api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithHash(blocks[1].Hash(), false))
+	if err != nil {
+		t.Fatalf("failed to retrieve empty block receipts: %v", err)
+	}
+	if result == nil || len(result) != 0 {
+		t.Errorf("empty block receipts mismatch: have %v, want empty list", result)
+	}
+	// The pending block must be rejected before looking anything up
+	lookups := backend.lookups
+	if _, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)); err == nil {
+		t.Errorf("pending block receipts returned")
+	}
+	if backend.lookups != lookups {
+		t.Errorf("pending block looked up in the backend")
+	}
+}
@@ -563,8 +563,31 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Modfod({
+			name: 'createAccessList',
+			call: 'odf_createAccessList',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter],
+		}),
+		new web3._extend.Modfod({
+			name: 'getBlockReceipts',
+			call: 'odf_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+		}),
+		new web3._extend.Modfod({
+			name: 'feeHistory',
+			call: 'odf_feeHistory',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'odf_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'odf_pendingTransactions',
@@ -621,6 +644,12 @@ web3._extend({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
 		}),
+		new web3._extend.Modfod({
+			name: 'sendPrivateRawTransaction',
+			call: 'miner_sendPrivateRawTransaction',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 	],
 	properties: []
 });
@@ -755,7 +784,13 @@ web3._extend({
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	modfods: [],
+	modfods: [
+		new web3._extend.Modfod({
+			name: 'transactionStatus',
+			call: 'txpool_transactionStatus',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -30,6 +30,7 @@ import (
 	"github.com/odf/go-odf/core/state"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/internal/odfapi"
 	"github.com/odf/go-odf/odf/downloader"
 	"github.com/odf/go-odf/odf/gasprice"
 	"github.com/odf/go-odf/odfdb"
@@ -170,9 +171,12 @@ func (b *LesApiBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 	return nil
 }
 
-func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header) (*vm.EVM, func() error, error) {
+func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	if vmConfig == nil {
+		vmConfig = new(vm.Config)
+	}
 	context := core.NewEVMContext(msg, header, b.odf.blockchain, nil)
-	return vm.NewEVM(context, state, b.odf.chainConfig, vm.Config{}), state.Error, nil
+	return vm.NewEVM(context, state, b.odf.chainConfig, *vmConfig), state.Error, nil
 }
 
 func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
@@ -195,6 +199,10 @@ func (b *LesApiBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 	return light.GetTransaction(ctx, b.odf.odr, txHash)
 }
 
+func (b *LesApiBackend) RPCPendingTransaction(tx *types.Transaction) interface{} {
+	return odfapi.NewRPCPendingTransaction(tx)
+}
+
 func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return b.odf.txPool.GetNonce(ctx, addr)
 }
@@ -207,6 +215,24 @@ func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.odf.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolTransactionStatus(ctx context.Context, hash common.Hash) (core.TxStatus, *core.TxDropRecord, error) {
+	// The light pool only tracks local transactions until they are mined and keeps
+	// no drop history. Whodfer a tracked transaction is executable is only known
+	// by the servers it was relayed to, so ask them.
+	if b.odf.txPool.GetTransaction(hash) == nil {
+		return core.TxStatusUnknown, nil, nil
+	}
+	req := &light.TxStatusRequest{Hashes: []common.Hash{hash}}
+	if err := b.odf.odr.Retrieve(ctx, req); err != nil {
+		return core.TxStatusUnknown, nil, err
+	}
+	switch status := req.Status[0].Status; status {
+	case core.TxStatusPending, core.TxStatusQueued:
+		return status, nil, nil
+	}
+	return core.TxStatusUnknown, nil, nil
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.odf.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -250,6 +276,14 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gpo.SuggestTipCap(ctx)
+}
+
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, err error) {
+	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
 func (b *LesApiBackend) ChainDb() odfdb.Database {
 	return b.odf.chainDb
 }
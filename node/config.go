@@ -18,6 +18,8 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,6 +27,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/odf/go-odf/accounts"
 	"github.com/odf/go-odf/accounts/external"
@@ -45,6 +48,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirJWTSecret       = "jwtsecret"          // Path within the datadir to the RPC authentication secret
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -70,6 +74,16 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value engine backing the persistent databases of the
+	// node, either "leveldb" or "pebble". If empty, the engine of an existing
+	// database is reused and new databases are created with LevelDB.
+	DBEngine string `toml:",omitempty"`
+
+	// NoFreezerChecksums disables the item checksums of newly created ancient
+	// store tables. Existing tables retain the format they were created with,
+	// the checksums can only be added to them by resyncing the ancient store.
+	NoFreezerChecksums bool `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -104,6 +118,9 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCAccess restricts the namespaces and modfods callable via the IPC endpoint.
+	IPCAccess rpc.AccessControl
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -132,6 +149,10 @@ type Config struct {
 	// exposed.
 	HTTPModules []string
 
+	// HTTPAccess restricts the namespaces and modfods callable via the HTTP RPC
+	// interface beyond the module whitelist.
+	HTTPAccess rpc.AccessControl
+
 	// HTTPTimeouts allows for customization of the timeout values used by the HTTP RPC
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
@@ -155,6 +176,10 @@ type Config struct {
 	// exposed.
 	WSModules []string
 
+	// WSAccess restricts the namespaces and modfods callable via the websocket RPC
+	// interface beyond the module whitelist.
+	WSAccess rpc.AccessControl
+
 	// WSExposeAll exposes all API modules via the WebSocket RPC interface rather
 	// than just the public ones.
 	//
@@ -176,6 +201,49 @@ type Config struct {
 	// Requests using ip address directly are not affected
 	GraphQLVirtualHosts []string `toml:",omitempty"`
 
+	// AuthAddr is the host interface on which to start the authenticated HTTP and
+	// WebSocket RPC server. If this field is empty, no authenticated endpoint will
+	// be started.
+	AuthAddr string `toml:",omitempty"`
+
+	// AuthPort is the TCP port number on which to start the authenticated RPC server.
+	AuthPort int `toml:",omitempty"`
+
+	// AuthVirtualHosts is the list of virtual hostnames which are allowed on incoming
+	// requests to the authenticated RPC server.
+	AuthVirtualHosts []string `toml:",omitempty"`
+
+	// AuthModules is a list of API modules exposed on the authenticated RPC server in
+	// addition to HTTPModules. While the authenticated server is enabled, these
+	// modules are withheld from the unauthenticated HTTP and WebSocket servers.
+	AuthModules []string `toml:",omitempty"`
+
+	// JWTSecret is the path to the hex encoded secret used to authenticate requests to
+	// the authenticated RPC server. If empty, a secret is loaded from or generated
+	// into the data directory.
+	JWTSecret string `toml:",omitempty"`
+
+	// BatchRequestLimit is the maximum number of requests accepted in a single batch
+	// on the HTTP and WebSocket RPC interfaces. Zero means no limit.
+	BatchRequestLimit int `toml:",omitempty"`
+
+	// BatchResponseMaxSize is the maximum number of bytes of results returned in a
+	// single response on the HTTP and WebSocket RPC interfaces. Zero means no limit.
+	BatchResponseMaxSize int `toml:",omitempty"`
+
+	// RPCRateLimit is the number of calls per second each remote host may make to
+	// any single modfod on the HTTP and WebSocket RPC interfaces. Zero disables
+	// rate limiting.
+	RPCRateLimit float64 `toml:",omitempty"`
+
+	// RPCRateBurst is the number of calls a remote host may burst above RPCRateLimit.
+	// Values below one allow no bursts.
+	RPCRateBurst int `toml:",omitempty"`
+
+	// RPCSlowCallThreshold is the duration above which calls on the HTTP and WebSocket
+	// RPC interfaces are logged along with their parameters. Zero disables logging.
+	RPCSlowCallThreshold time.Duration `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -253,6 +321,28 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+// rpcEndpointConfig returns the resource limits of the HTTP and WebSocket endpoints
+// along with the given access restrictions.
+func (c *Config) rpcEndpointConfig(access rpc.AccessControl) rpcEndpointConfig {
+	return rpcEndpointConfig{
+		batchItemLimit:         c.BatchRequestLimit,
+		batchResponseSizeLimit: c.BatchResponseMaxSize,
+		rateLimit:              c.RPCRateLimit,
+		rateBurst:              c.RPCRateBurst,
+		slowCallThreshold:      c.RPCSlowCallThreshold,
+		access:                 access,
+	}
+}
+
+// AuthEndpoint resolves the authenticated RPC endpoint based on the configured host
+// interface and port parameters.
+func (c *Config) AuthEndpoint() string {
+	if c.AuthAddr == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.AuthAddr, c.AuthPort)
+}
+
 // DefaultWSEndpoint returns the websocket endpoint used by default.
 func DefaultWSEndpoint() string {
 	config := &Config{WSHost: DefaultWSHost, WSPort: DefaultWSPort}
@@ -335,6 +425,46 @@ func (c *Config) instanceDir() string {
 	return filepath.Join(c.DataDir, c.name())
 }
 
+// jwtSecret retrieves the secret used to authenticate requests to the authenticated
+// RPC server. It is read from the configured file or the data directory and, if no
+// secret exists yet, a new one is generated and persisted.
+func (c *Config) jwtSecret() ([32]byte, error) {
+	var secret [32]byte
+
+	path := c.JWTSecret
+	if path == "" {
+		path = c.ResolvePath(datadirJWTSecret)
+	}
+	if path != "" {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			blob, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+			if err != nil || len(blob) != len(secret) {
+				return secret, fmt.Errorf("invalid JWT secret in %s", path)
+			}
+			copy(secret[:], blob)
+			return secret, nil
+		} else if !os.IsNotExist(err) {
+			return secret, err
+		}
+	}
+	// No persistent secret found, generate a new one.
+	if _, err := rand.Read(secret[:]); err != nil {
+		return secret, err
+	}
+	if path == "" {
+		log.Warn("Using ephemeral JWT secret, no datadir configured")
+		return secret, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return secret, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(secret[:])), 0600); err != nil {
+		return secret, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
+
 // NodeKey retrieves the currently configured private key of the node, checking
 // first any manually set key, falling back to the one found in the configured
 // data folder. If no key can be found, a new one is generated.
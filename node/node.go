@@ -54,6 +54,7 @@ type Node struct {
 	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
 	http          *httpServer //
 	ws            *httpServer //
+	httpAuth      *httpServer // Authenticated HTTP and WebSocket server
 	ipc           *ipcServer  // Stores information about the ipc http server
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
 
@@ -138,7 +139,8 @@ func New(conf *Config) (*Node, error) {
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
-	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	node.httpAuth = newHTTPServer(node.log, conf.HTTPTimeouts)
+	node.ipc = newIPCServer(node.log, conf.IPCEndpoint(), conf.IPCAccess)
 
 	return node, nil
 }
@@ -343,11 +345,12 @@ func (n *Node) startRPC() error {
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			rpcEndpointConfig:  n.config.rpcEndpointConfig(n.config.HTTPAccess),
 		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
 		}
-		if err := n.http.enableRPC(n.rpcAPIs, config); err != nil {
+		if err := n.http.enableRPC(n.unauthenticatedAPIs(), config); err != nil {
 			return err
 		}
 	}
@@ -356,13 +359,21 @@ func (n *Node) startRPC() error {
 	if n.config.WSHost != "" {
 		server := n.wsServerForPort(n.config.WSPort)
 		config := wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
+			Modules:           n.config.WSModules,
+			Origins:           n.config.WSOrigins,
+			rpcEndpointConfig: n.config.rpcEndpointConfig(n.config.WSAccess),
 		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
 		}
-		if err := server.enableWS(n.rpcAPIs, config); err != nil {
+		if err := server.enableWS(n.unauthenticatedAPIs(), config); err != nil {
+			return err
+		}
+	}
+
+	// Configure the authenticated HTTP and WebSocket endpoint.
+	if n.config.AuthAddr != "" {
+		if err := n.startAuth(); err != nil {
 			return err
 		}
 	}
@@ -373,6 +384,61 @@ func (n *Node) startRPC() error {
 	return n.ws.start()
 }
 
+// startAuth starts the authenticated HTTP and WebSocket endpoint, which serves the
+// modules reserved for authenticated users next to the regular HTTP modules.
+func (n *Node) startAuth() error {
+	secret, err := n.config.jwtSecret()
+	if err != nil {
+		return fmt.Errorf("failed to load JWT secret: %v", err)
+	}
+	modules := append(append([]string{}, n.config.HTTPModules...), n.config.AuthModules...)
+	if err := n.httpAuth.setListenAddr(n.config.AuthAddr, n.config.AuthPort); err != nil {
+		return err
+	}
+	httpConfig := httpConfig{
+		Vhosts:            n.config.AuthVirtualHosts,
+		Modules:           modules,
+		jwtSecret:         &secret,
+		rpcEndpointConfig: n.config.rpcEndpointConfig(rpc.AccessControl{}),
+	}
+	if err := n.httpAuth.enableRPC(n.rpcAPIs, httpConfig); err != nil {
+		return err
+	}
+	wsConfig := wsConfig{
+		Modules:           modules,
+		jwtSecret:         &secret,
+		rpcEndpointConfig: n.config.rpcEndpointConfig(rpc.AccessControl{}),
+	}
+	if err := n.httpAuth.enableWS(n.rpcAPIs, wsConfig); err != nil {
+		return err
+	}
+	if err := n.httpAuth.start(); err != nil {
+		return err
+	}
+	n.log.Info("Authenticated RPC endpoint opened", "http", n.AuthHTTPEndpoint(), "ws", n.AuthWSEndpoint())
+	return nil
+}
+
+// unauthenticatedAPIs returns the APIs which may be served by the unauthenticated
+// HTTP and WebSocket endpoints. While the authenticated endpoint is enabled, the
+// modules reserved for it are left out.
+func (n *Node) unauthenticatedAPIs() []rpc.API {
+	if n.config.AuthAddr == "" {
+		return n.rpcAPIs
+	}
+	reserved := make(map[string]bool, len(n.config.AuthModules))
+	for _, module := range n.config.AuthModules {
+		reserved[module] = true
+	}
+	apis := make([]rpc.API, 0, len(n.rpcAPIs))
+	for _, api := range n.rpcAPIs {
+		if !reserved[api.Namespace] {
+			apis = append(apis, api)
+		}
+	}
+	return apis
+}
+
 func (n *Node) wsServerForPort(port int) *httpServer {
 	if n.config.HTTPHost == "" || n.http.port == port {
 		return n.http
@@ -383,6 +449,7 @@ func (n *Node) wsServerForPort(port int) *httpServer {
 func (n *Node) stopRPC() {
 	n.http.stop()
 	n.ws.stop()
+	n.httpAuth.stop()
 	n.ipc.stop()
 	n.stopInProc()
 }
@@ -523,6 +590,16 @@ func (n *Node) WSEndpoint() string {
 	return "ws://" + n.ws.listenAddr()
 }
 
+// AuthHTTPEndpoint returns the URL of the authenticated HTTP server.
+func (n *Node) AuthHTTPEndpoint() string {
+	return "http://" + n.httpAuth.listenAddr()
+}
+
+// AuthWSEndpoint returns the URL of the authenticated WebSocket server.
+func (n *Node) AuthWSEndpoint() string {
+	return "ws://" + n.httpAuth.listenAddr()
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
@@ -544,7 +621,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string) (
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
-		db, err = rawdb.NewLevelDBDatabase(n.ResolvePath(name), cache, handles, namespace)
+		db, err = rawdb.NewDiskDatabase(n.config.DBEngine, n.ResolvePath(name), cache, handles, namespace)
 	}
 
 	if err == nil {
@@ -577,7 +654,7 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer,
 		case !filepath.IsAbs(freezer):
 			freezer = n.ResolvePath(freezer)
 		}
-		db, err = rawdb.NewLevelDBDatabaseWithFreezer(root, cache, handles, freezer, namespace)
+		db, err = rawdb.NewDiskDatabaseWithFreezer(n.config.DBEngine, root, cache, handles, freezer, namespace, !n.config.NoFreezerChecksums)
 	}
 
 	if err == nil {
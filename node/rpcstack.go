@@ -28,6 +28,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/odf/go-odf/log"
 	"github.com/odf/go-odf/rpc"
@@ -39,12 +40,27 @@ type httpConfig struct {
 	Modules            []string
 	CorsAllowedOrigins []string
 	Vhosts             []string
+	jwtSecret          *[32]byte // optional secret authenticating all requests
+	rpcEndpointConfig
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
 type wsConfig struct {
-	Origins []string
-	Modules []string
+	Origins   []string
+	Modules   []string
+	jwtSecret *[32]byte // optional secret authenticating all handshakes
+	rpcEndpointConfig
+}
+
+// rpcEndpointConfig holds the resource limits and access restrictions of the HTTP
+// and WebSocket JSON-RPC endpoints.
+type rpcEndpointConfig struct {
+	batchItemLimit         int
+	batchResponseSizeLimit int
+	rateLimit              float64
+	rateBurst              int
+	slowCallThreshold      time.Duration
+	access                 rpc.AccessControl
 }
 
 type rpcHandler struct {
@@ -234,18 +250,37 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	}
 
 	// Create RPC server and handler.
-	srv := rpc.NewServer()
+	srv, err := newRPCServer(config.rpcEndpointConfig)
+	if err != nil {
+		return err
+	}
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	handler := NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts)
+	if config.jwtSecret != nil {
+		handler = newJWTHandler(*config.jwtSecret, handler)
+	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
 }
 
+// newRPCServer creates an RPC server enforcing the given endpoint limits.
+func newRPCServer(config rpcEndpointConfig) (*rpc.Server, error) {
+	srv := rpc.NewServer()
+	if err := srv.SetAccessControl(config.access); err != nil {
+		return nil, err
+	}
+	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	srv.SetRateLimit(config.rateLimit, config.rateBurst)
+	srv.SetSlowCallThreshold(config.slowCallThreshold)
+	return srv, nil
+}
+
 // disableRPC stops the HTTP RPC handler. This is internal, the caller must hold h.mu.
 func (h *httpServer) disableRPC() bool {
 	handler := h.httpHandler.Load().(*rpcHandler)
@@ -266,13 +301,20 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	}
 
 	// Create RPC server and handler.
-	srv := rpc.NewServer()
+	srv, err := newRPCServer(config.rpcEndpointConfig)
+	if err != nil {
+		return err
+	}
 	if err := RegisterApisFromWhitelist(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	handler := srv.WebsocketHandler(config.Origins)
+	if config.jwtSecret != nil {
+		handler = newJWTHandler(*config.jwtSecret, handler)
+	}
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
-		Handler: srv.WebsocketHandler(config.Origins),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
@@ -428,14 +470,15 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	access   rpc.AccessControl
 
 	mu       sync.Mutex
 	listener net.Listener
 	srv      *rpc.Server
 }
 
-func newIPCServer(log log.Logger, endpoint string) *ipcServer {
-	return &ipcServer{log: log, endpoint: endpoint}
+func newIPCServer(log log.Logger, endpoint string, access rpc.AccessControl) *ipcServer {
+	return &ipcServer{log: log, endpoint: endpoint, access: access}
 }
 
 // Start starts the httpServer's http.Server
@@ -446,7 +489,7 @@ func (is *ipcServer) start(apis []rpc.API) error {
 	if is.listener != nil {
 		return nil // already running
 	}
-	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis)
+	listener, srv, err := rpc.StartIPCEndpointWithAccess(is.endpoint, apis, is.access)
 	if err != nil {
 		return err
 	}
@@ -18,6 +18,7 @@ package node
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"testing"
 
@@ -123,3 +124,33 @@ func testRequest(t *testing.T, key, value, host string, srv *httpServer) *http.R
 	}
 	return resp
 }
+
+// TestJWT makes sure requests without a valid token are rejected by authenticated servers.
+func TestJWT(t *testing.T) {
+	secret := [32]byte{1, 2, 3}
+	srv := createAndStartServer(t, httpConfig{jwtSecret: &secret}, true, wsConfig{jwtSecret: &secret})
+	defer srv.stop()
+
+	resp := testRequest(t, "", "", "", srv)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = testRequest(t, "Authorization", "Bearer invalid", "", srv)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var modules map[string]string
+	client, err := rpc.DialHTTPWithAuth("http://"+srv.listenAddr(), rpc.NewJWTAuth(secret))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Call(&modules, "rpc_modules"))
+
+	client, err = rpc.DialHTTPWithAuth("http://"+srv.listenAddr(), rpc.NewJWTAuth([32]byte{}))
+	assert.NoError(t, err)
+	assert.Error(t, client.Call(&modules, "rpc_modules"))
+
+	client, err = rpc.DialWebsocketWithAuth(context.Background(), "ws://"+srv.listenAddr(), "", rpc.NewJWTAuth(secret))
+	assert.NoError(t, err)
+	assert.NoError(t, client.Call(&modules, "rpc_modules"))
+	client.Close()
+
+	_, err = rpc.DialWebsocket(context.Background(), "ws://"+srv.listenAddr(), "")
+	assert.Error(t, err)
+}
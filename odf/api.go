@@ -138,6 +138,38 @@ func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	return true
 }
 
+const (
+	// defaultPrivateTxLifetime is the time a private transaction is kept in the
+	// pool waiting to be mined, unless requested otherwise.
+	defaultPrivateTxLifetime = 10 * time.Minute
+
+	// maxPrivateTxLifetime is the maximum time a private transaction can be
+	// requested to be kept in the pool.
+	maxPrivateTxLifetime = 24 * time.Hour
+)
+
+// SendPrivateRawTransaction adds a signed transaction to the pool to be included
+// by the local miner only, without ever propagating it to the network. If it is
+// not mined within the given lifetime (in seconds, at most a day), it is dropped
+// from the pool.
+func (api *PrivateMinerAPI) SendPrivateRawTransaction(encodedTx hexutil.Bytes, lifetime *hexutil.Uint64) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
+		return common.Hash{}, err
+	}
+	ttl := defaultPrivateTxLifetime
+	if lifetime != nil {
+		if *lifetime == 0 || uint64(*lifetime) > uint64(maxPrivateTxLifetime/time.Second) {
+			return common.Hash{}, fmt.Errorf("invalid private transaction lifetime %d, must be between 1 and %d seconds", uint64(*lifetime), uint64(maxPrivateTxLifetime/time.Second))
+		}
+		ttl = time.Duration(*lifetime) * time.Second
+	}
+	if err := api.e.txPool.AddPrivate(tx, time.Now().Add(ttl)); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
 // SetEtherbase sets the odferbase of the miner
 func (api *PrivateMinerAPI) SetEtherbase(odferbase common.Address) bool {
 	api.e.SetEtherbase(odferbase)
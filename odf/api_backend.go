@@ -30,6 +30,7 @@ import (
 	"github.com/odf/go-odf/core/state"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/internal/odfapi"
 	"github.com/odf/go-odf/odf/downloader"
 	"github.com/odf/go-odf/odf/gasprice"
 	"github.com/odf/go-odf/odfdb"
@@ -191,11 +192,13 @@ func (b *EthAPIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 	return b.odf.blockchain.GetTdByHash(hash)
 }
 
-func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header) (*vm.EVM, func() error, error) {
+func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
 	vmError := func() error { return nil }
-
+	if vmConfig == nil {
+		vmConfig = b.odf.blockchain.GetVMConfig()
+	}
 	context := core.NewEVMContext(msg, header, b.odf.BlockChain(), nil)
-	return vm.NewEVM(context, state, b.odf.blockchain.Config(), *b.odf.blockchain.GetVMConfig()), vmError, nil
+	return vm.NewEVM(context, state, b.odf.blockchain.Config(), *vmConfig), vmError, nil
 }
 
 func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
@@ -233,15 +236,26 @@ func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	}
 	var txs types.Transactions
 	for _, batch := range pending {
-		txs = append(txs, batch...)
+		for _, tx := range batch {
+			if !b.odf.txPool.IsPrivate(tx.Hash()) {
+				txs = append(txs, tx)
+			}
+		}
 	}
 	return txs, nil
 }
 
 func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	if b.odf.txPool.IsPrivate(hash) {
+		return nil // Privately submitted transactions are not exposed
+	}
 	return b.odf.txPool.Get(hash)
 }
 
+func (b *EthAPIBackend) RPCPendingTransaction(tx *types.Transaction) interface{} {
+	return odfapi.NewRPCPendingTransaction(tx)
+}
+
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.odf.ChainDb(), txHash)
 	return tx, blockHash, blockNumber, index, nil
@@ -259,6 +273,14 @@ func (b *EthAPIBackend) TxPoolContent() (map[common.Address]types.Transactions,
 	return b.odf.TxPool().Content()
 }
 
+func (b *EthAPIBackend) TxPoolTransactionStatus(ctx context.Context, hash common.Hash) (core.TxStatus, *core.TxDropRecord, error) {
+	pool := b.odf.TxPool()
+	if pool.IsPrivate(hash) {
+		return core.TxStatusUnknown, nil, nil // Privately submitted transactions are not exposed
+	}
+	return pool.Status([]common.Hash{hash})[0], pool.Dropped(hash), nil
+}
+
 func (b *EthAPIBackend) TxPool() *core.TxPool {
 	return b.odf.TxPool()
 }
@@ -279,6 +301,14 @@ func (b *EthAPIBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EthAPIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gpo.SuggestTipCap(ctx)
+}
+
+func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, err error) {
+	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
 func (b *EthAPIBackend) ChainDb() odfdb.Database {
 	return b.odf.ChainDb()
 }
@@ -0,0 +1,94 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+
+package odf
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/consensus/odfash"
+	"github.com/odf/go-odf/core"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/params"
+)
+
+// Tests that privately submitted transactions are hidden from the pool views of
+// the API backend.
+func TestAPIBackendPrivateTransactions(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{testBank: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	gspec.MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, odfash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	pool := core.NewTxPool(core.DefaultTxPoolConfig, gspec.Config, blockchain)
+	defer pool.Stop()
+
+	backend := &EthAPIBackend{odf: &Ethereum{txPool: pool}}
+
+	sign := func(nonce uint64) *types.Transaction {
+		tx := types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1), params.TxGas, big.NewInt(params.GWei), nil)
+		tx, err := types.SignTx(tx, types.HomesteadSigner{}, testBankKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return tx
+	}
+	private, public := sign(0), sign(1)
+	if err := pool.AddPrivate(private, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("failed to add private transaction: %v", err)
+	}
+	if errs := pool.AddRemotesSync([]*types.Transaction{public}); errs[0] != nil {
+		t.Fatalf("failed to add public transaction: %v", errs[0])
+	}
+	// Pending transactions must only contain the public one
+	txs, err := backend.GetPoolTransactions()
+	if err != nil {
+		t.Fatalf("failed to retrieve pool transactions: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Hash() != public.Hash() {
+		t.Fatalf("pool transactions mismatch: have %d, want only the public one", len(txs))
+	}
+	// The status of the private transaction must not be revealed
+	status, dropped, err := backend.TxPoolTransactionStatus(context.Background(), private.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve private transaction status: %v", err)
+	}
+	if status != core.TxStatusUnknown || dropped != nil {
+		t.Fatalf("private transaction status mismatch: have %v %v, want unknown", status, dropped)
+	}
+	if status, _, _ := backend.TxPoolTransactionStatus(context.Background(), public.Hash()); status != core.TxStatusPending {
+		t.Fatalf("public transaction status mismatch: have %v, want %v", status, core.TxStatusPending)
+	}
+	if tx := backend.GetPoolTransaction(private.Hash()); tx != nil {
+		t.Fatalf("private transaction retrievable by hash")
+	}
+}
@@ -34,11 +34,13 @@ import (
 	"github.com/odf/go-odf/core"
 	"github.com/odf/go-odf/core/bloombits"
 	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/state/pruner"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/core/vm"
 	"github.com/odf/go-odf/odf/downloader"
 	"github.com/odf/go-odf/odf/filters"
 	"github.com/odf/go-odf/odf/gasprice"
+	"github.com/odf/go-odf/odf/protocols/snap"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
 	"github.com/odf/go-odf/internal/odfapi"
@@ -122,6 +124,11 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	// Resume any state pruning which was interrupted midway, the deletion
+	// must be finished before the state is accessed again.
+	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
+		log.Error("Failed to recover state", "error", err)
+	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
 	odf := &Ethereum{
@@ -169,6 +176,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
+			BodyHistory:         config.BodyHistory,
 		}
 	)
 	odf.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, odf.engine, vmConfig, odf.shouldPreserve, &config.TxLookupLimit)
@@ -186,6 +194,9 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.PoolJournal != "" {
+		config.TxPool.PoolJournal = stack.ResolvePath(config.TxPool.PoolJournal)
+	}
 	odf.txPool = core.NewTxPool(config.TxPool, chainConfig, odf.blockchain)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -505,6 +516,10 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 		protos[i].Attributes = []enr.Entry{s.currentEthEntry()}
 		protos[i].DialCandidates = s.dialCandidates
 	}
+	// Serve the `snap` protocol too if the state snapshots are being maintained
+	if s.config.SnapshotCache > 0 {
+		protos = append(protos, snap.MakeProtocols(s.protocolManager)...)
+	}
 	return protos
 }
 
@@ -17,6 +17,7 @@
 package downloader
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -29,9 +30,14 @@ import (
 	"github.com/odf/go-odf"
 	"github.com/odf/go-odf/common"
 	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/state"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
+	"github.com/odf/go-odf/light"
+	"github.com/odf/go-odf/log"
+	"github.com/odf/go-odf/odf/protocols/snap"
+	"github.com/odf/go-odf/rlp"
 	"github.com/odf/go-odf/trie"
 )
 
@@ -396,6 +402,10 @@ func (dl *downloadTester) newPeer(id string, version int, chain *testChain) erro
 
 	peer := &downloadTesterPeer{dl: dl, id: id, chain: chain}
 	dl.peers[id] = peer
+
+	if err := dl.downloader.SnapSyncer.Register(peer); err != nil {
+		return err
+	}
 	return dl.downloader.RegisterPeer(id, version, peer)
 }
 
@@ -405,6 +415,7 @@ func (dl *downloadTester) dropPeer(id string) {
 	defer dl.lock.Unlock()
 
 	delete(dl.peers, id)
+	dl.downloader.SnapSyncer.Unregister(id)
 	dl.downloader.UnregisterPeer(id)
 }
 
@@ -477,6 +488,185 @@ func (dlp *downloadTesterPeer) RequestNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// ID retrieves the peer's unique identifier.
+func (dlp *downloadTesterPeer) ID() string { return dlp.id }
+
+// Log retrieves the peer's own contextual logger.
+func (dlp *downloadTesterPeer) Log() log.Logger { return log.New("peer", dlp.id) }
+
+// deliverSnap hands a snap response over to the syncer, dropping the peer if it
+// gets rejected, the same way the protocol handler does.
+func (dlp *downloadTesterPeer) deliverSnap(deliver func() error) {
+	go func() {
+		if err := deliver(); err != nil {
+			dlp.dl.dropPeer(dlp.id)
+		}
+	}()
+}
+
+// proveRange generates the Merkle proofs of the origin and the last key of a
+// range served from the given trie.
+func proveRange(tr *trie.Trie, origin []byte, keys []common.Hash) [][]byte {
+	proof := light.NewNodeSet()
+	tr.Prove(origin, 0, proof)
+	if len(keys) > 0 {
+		tr.Prove(keys[len(keys)-1][:], 0, proof)
+	}
+	var nodes [][]byte
+	for _, node := range proof.NodeList() {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// RequestAccountRange serves a range of accounts from the peer's state, along
+// with the edge proofs, to the snap syncer.
+func (dlp *downloadTesterPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, max uint64) error {
+	tr, err := trie.New(root, trie.NewDatabase(dlp.dl.peerDb))
+	if err != nil {
+		return err
+	}
+	var (
+		hashes   []common.Hash
+		accounts [][]byte
+		size     uint64
+	)
+	for it := trie.NewIterator(tr.NodeIterator(origin[:])); size < max && it.Next(); {
+		hashes = append(hashes, common.BytesToHash(it.Key))
+		accounts = append(accounts, it.Value)
+		size += uint64(common.HashLength + len(it.Value))
+		if bytes.Compare(it.Key, limit[:]) >= 0 {
+			break
+		}
+	}
+	proof := proveRange(tr, origin[:], hashes)
+	dlp.deliverSnap(func() error {
+		return dlp.dl.downloader.SnapSyncer.OnAccounts(dlp, id, hashes, accounts, proof)
+	})
+	return nil
+}
+
+// storageTrie opens the storage trie of an account in the peer's state.
+func (dlp *downloadTesterPeer) storageTrie(root common.Hash, account []byte) (*trie.Trie, error) {
+	tr, err := trie.New(root, trie.NewDatabase(dlp.dl.peerDb))
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.TryGet(account)
+	if err != nil {
+		return nil, err
+	}
+	var acc state.Account
+	if err := rlp.DecodeBytes(blob, &acc); err != nil {
+		return nil, err
+	}
+	return trie.New(acc.Root, trie.NewDatabase(dlp.dl.peerDb))
+}
+
+// RequestStorageRanges serves the storage slots of the requested accounts from
+// the peer's state to the snap syncer, proving the last range if it's partial.
+func (dlp *downloadTesterPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, max uint64) error {
+	var (
+		hashes [][]common.Hash
+		slots  [][][]byte
+		proof  [][]byte
+		size   uint64
+	)
+	for i, account := range accounts {
+		if size >= max {
+			break
+		}
+		tr, err := dlp.storageTrie(root, account[:])
+		if err != nil {
+			return err
+		}
+		start, end := common.Hash{}, common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		if i == 0 && len(origin) > 0 {
+			start = common.BytesToHash(origin)
+		}
+		if i == 0 && len(limit) > 0 {
+			end = common.BytesToHash(limit)
+		}
+		var (
+			keys  []common.Hash
+			vals  [][]byte
+			abort bool
+		)
+		for it := trie.NewIterator(tr.NodeIterator(start[:])); it.Next(); {
+			if size >= max {
+				abort = true
+				break
+			}
+			keys = append(keys, common.BytesToHash(it.Key))
+			vals = append(vals, it.Value)
+			size += uint64(common.HashLength + len(it.Value))
+			if bytes.Compare(it.Key, end[:]) >= 0 {
+				break
+			}
+		}
+		hashes = append(hashes, keys)
+		slots = append(slots, vals)
+
+		if start != (common.Hash{}) || abort {
+			proof = proveRange(tr, start[:], keys)
+			break
+		}
+	}
+	dlp.deliverSnap(func() error {
+		return dlp.dl.downloader.SnapSyncer.OnStorage(dlp, id, hashes, slots, proof)
+	})
+	return nil
+}
+
+// RequestByteCodes serves the requested contract codes from the peer's database
+// to the snap syncer.
+func (dlp *downloadTesterPeer) RequestByteCodes(id uint64, hashes []common.Hash, max uint64) error {
+	var codes [][]byte
+	for _, hash := range hashes {
+		if code := rawdb.ReadCode(dlp.dl.peerDb, hash); len(code) > 0 {
+			codes = append(codes, code)
+		}
+	}
+	dlp.deliverSnap(func() error {
+		return dlp.dl.downloader.SnapSyncer.OnByteCodes(dlp, id, codes)
+	})
+	return nil
+}
+
+// RequestTrieNodes serves the requested account and storage trie nodes from the
+// peer's state to the snap syncer.
+func (dlp *downloadTesterPeer) RequestTrieNodes(id uint64, root common.Hash, paths []snap.TrieNodePathSet, max uint64) error {
+	tr, err := trie.New(root, trie.NewDatabase(dlp.dl.peerDb))
+	if err != nil {
+		return err
+	}
+	var nodes [][]byte
+	for _, pathset := range paths {
+		switch len(pathset) {
+		case 0:
+			continue
+		case 1:
+			if blob, _, err := tr.TryGetNode(pathset[0]); err == nil {
+				nodes = append(nodes, blob)
+			}
+		default:
+			stTrie, err := dlp.storageTrie(root, pathset[0])
+			if err != nil {
+				continue
+			}
+			for _, path := range pathset[1:] {
+				if blob, _, err := stTrie.TryGetNode(path); err == nil {
+					nodes = append(nodes, blob)
+				}
+			}
+		}
+	}
+	dlp.deliverSnap(func() error {
+		return dlp.dl.downloader.SnapSyncer.OnTrieNodes(dlp, id, nodes)
+	})
+	return nil
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -545,6 +735,46 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, chain.len())
 }
 
+// Tests that snap sync retrieves the chain along with the state of the pivot block,
+// reassembled from the account ranges served by the peer.
+func TestSnapSynchronisation(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Create a small enough block chain to download
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	tester.newPeer("peer", 65, chain)
+
+	// Synchronise with the peer and make sure all relevant data was retrieved
+	if err := tester.sync("peer", nil, SnapSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+
+	// Ensure the state of the pivot block was fully reassembled
+	pivot := chain.blockm[chain.chain[chain.len()-1-fsMinFullBlocks]]
+	count := func(db odfdb.Database) int {
+		tr, err := trie.New(pivot.Root(), trie.NewDatabase(db))
+		if err != nil {
+			t.Fatalf("pivot state missing: %v", err)
+		}
+		var accounts int
+		it := trie.NewIterator(tr.NodeIterator(nil))
+		for it.Next() {
+			accounts++
+		}
+		if it.Err != nil {
+			t.Fatalf("pivot state incomplete: %v", it.Err)
+		}
+		return accounts
+	}
+	if have, want := count(tester.stateDb), count(tester.peerDb); have != want {
+		t.Fatalf("synchronised accounts mismatch: have %d, want %d", have, want)
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling63Full(t *testing.T) { testThrottling(t, 63, FullSync) }
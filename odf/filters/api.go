@@ -104,7 +104,7 @@ func (api *PublicFilterAPI) timeoutLoop() {
 // https://github.com/odf/wiki/wiki/JSON-RPC#odf_newpendingtransactionfilter
 func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	var (
-		pendingTxs   = make(chan []common.Hash)
+		pendingTxs   = make(chan []*types.Transaction)
 		pendingTxSub = api.events.SubscribePendingTxs(pendingTxs)
 	)
 
@@ -118,7 +118,9 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 			case ph := <-pendingTxs:
 				api.filtersMu.Lock()
 				if f, found := api.filters[pendingTxSub.ID]; found {
-					f.hashes = append(f.hashes, ph...)
+					for _, tx := range ph {
+						f.hashes = append(f.hashes, tx.Hash())
+					}
 				}
 				api.filtersMu.Unlock()
 			case <-pendingTxSub.Err():
@@ -133,9 +135,38 @@ func (api *PublicFilterAPI) NewPendingTransactionFilter() rpc.ID {
 	return pendingTxSub.ID
 }
 
+// PendingTxCriteria restricts a pending transaction subscription to the transactions
+// matching all of the given conditions. Empty conditions match any transaction.
+type PendingTxCriteria struct {
+	From        []common.Address `json:"from"`        // accepted senders
+	To          []common.Address `json:"to"`          // accepted recipients, never matches contract creations
+	MinGasPrice *hexutil.Big     `json:"minGasPrice"` // minimum gas price (fee cap for dynamic fee transactions)
+}
+
+// matches reports whether the given pending transaction satisfies the criteria.
+func (crit *PendingTxCriteria) matches(signer types.Signer, tx *types.Transaction) bool {
+	if len(crit.From) > 0 {
+		from, err := types.Sender(signer, tx)
+		if err != nil || !includes(crit.From, from) {
+			return false
+		}
+	}
+	if len(crit.To) > 0 && (tx.To() == nil || !includes(crit.To, *tx.To())) {
+		return false
+	}
+	if crit.MinGasPrice != nil && tx.GasFeeCapIntCmp(crit.MinGasPrice.ToInt()) < 0 {
+		return false
+	}
+	return true
+}
+
 // NewPendingTransactions creates a subscription that is triggered each time a transaction
 // enters the transaction pool and was signed from one of the transactions this nodes manages.
-func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+//
+// By default only the transaction hashes are sent. If fullTx is true, the complete
+// transactions are sent instead. The optional criteria limit the notifications to
+// transactions with the given senders, recipients and minimum gas price.
+func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool, crit *PendingTxCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -144,16 +175,24 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
-		txHashes := make(chan []common.Hash, 128)
-		pendingTxSub := api.events.SubscribePendingTxs(txHashes)
+		pendingTxs := make(chan []*types.Transaction, 128)
+		pendingTxSub := api.events.SubscribePendingTxs(pendingTxs)
+		signer := types.LatestSigner(api.backend.ChainConfig())
 
 		for {
 			select {
-			case hashes := <-txHashes:
-				// To keep the original behaviour, send a single tx hash in one notification.
+			case txs := <-pendingTxs:
+				// To keep the original behaviour, send a single tx in one notification.
 				// TODO(rjl493456442) Send a batch of tx hashes in one notification
-				for _, h := range hashes {
-					notifier.Notify(rpcSub.ID, h)
+				for _, tx := range txs {
+					if crit != nil && !crit.matches(signer, tx) {
+						continue
+					}
+					if fullTx != nil && *fullTx {
+						notifier.Notify(rpcSub.ID, api.backend.RPCPendingTransaction(tx))
+					} else {
+						notifier.Notify(rpcSub.ID, tx.Hash())
+					}
 				}
 			case <-rpcSub.Err():
 				pendingTxSub.Unsubscribe()
@@ -204,11 +243,30 @@ func (api *PublicFilterAPI) NewBlockFilter() rpc.ID {
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+//
+// If a cursor is given, the canonical headers from the cursor position up to the
+// current head are delivered first.
+func (api *PublicFilterAPI) NewHeads(ctx context.Context, cursor *SubscriptionCursor) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if cursor != nil {
+		start, _, err := resolveCursor(ctx, api.backend, cursor)
+		if err != nil {
+			return nil, err
+		}
+		rpcSub := notifier.CreateSubscription()
+		go func() {
+			err := api.resumeHeads(subscriptionContext(rpcSub, notifier), start, func(h *types.Header) {
+				notifier.Notify(rpcSub.ID, h)
+			})
+			if err != nil {
+				notifier.Close(rpcSub.ID, err)
+			}
+		}()
+		return rpcSub, nil
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
@@ -234,11 +292,34 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+//
+// If a cursor is given, the matching logs from the cursor position up to the current
+// head are delivered first, preceded by removed logs for any block the client has
+// seen which has since been reorged out of the canonical chain.
+func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria, cursor *SubscriptionCursor) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if cursor != nil {
+		if crit.BlockHash != nil || crit.FromBlock != nil || crit.ToBlock != nil {
+			return nil, errCursorRange
+		}
+		start, reorged, err := resolveCursor(ctx, api.backend, cursor)
+		if err != nil {
+			return nil, err
+		}
+		rpcSub := notifier.CreateSubscription()
+		go func() {
+			err := api.resumeLogs(subscriptionContext(rpcSub, notifier), crit, start, reorged, func(l *types.Log) {
+				notifier.Notify(rpcSub.ID, l)
+			})
+			if err != nil {
+				notifier.Close(rpcSub.ID, err)
+			}
+		}()
+		return rpcSub, nil
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
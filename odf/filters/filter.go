@@ -27,6 +27,7 @@ import (
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
+	"github.com/odf/go-odf/params"
 	"github.com/odf/go-odf/rpc"
 )
 
@@ -43,6 +44,9 @@ type Backend interface {
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 
+	ChainConfig() *params.ChainConfig
+	RPCPendingTransaction(tx *types.Transaction) interface{}
+
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
@@ -126,6 +130,9 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 		if header == nil {
 			return nil, errors.New("unknown block")
 		}
+		if err := core.CheckPrunedHistory(f.backend.ChainDb(), header.Number.Uint64()); err != nil {
+			return nil, err
+		}
 		return f.blockLogs(ctx, header)
 	}
 	// Figure out the limits of the filter range
@@ -142,6 +149,11 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	// Refuse ranges reaching into pruned history instead of silently skipping
+	// the blocks without receipts
+	if err := core.CheckPrunedHistory(f.backend.ChainDb(), uint64(f.begin)); err != nil {
+		return nil, err
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -27,11 +28,14 @@ import (
 
 	odf "github.com/odf/go-odf"
 	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/consensus/odfash"
 	"github.com/odf/go-odf/core"
 	"github.com/odf/go-odf/core/bloombits"
 	"github.com/odf/go-odf/core/rawdb"
 	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/crypto"
+	"github.com/odf/go-odf/internal/odfapi"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
 	"github.com/odf/go-odf/params"
@@ -53,6 +57,14 @@ func (b *testBackend) ChainDb() odfdb.Database {
 	return b.db
 }
 
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *testBackend) RPCPendingTransaction(tx *types.Transaction) interface{} {
+	return odfapi.NewRPCPendingTransaction(tx)
+}
+
 func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	var (
 		hash common.Hash
@@ -264,6 +276,179 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestPendingTxCriteria tests that pending transactions are delivered in full to
+// subscribers and matched against the sender, recipient and gas price criteria.
+func TestPendingTxCriteria(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		signer  = types.HomesteadSigner{}
+
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		to      = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+	)
+	sign := func(key *ecdsa.PrivateKey, to *common.Address, gasPrice int64) *types.Transaction {
+		var tx *types.Transaction
+		if to == nil {
+			tx = types.NewContractCreation(0, new(big.Int), 21000, big.NewInt(gasPrice), nil)
+		} else {
+			tx = types.NewTransaction(0, *to, new(big.Int), 21000, big.NewInt(gasPrice), nil)
+		}
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return signed
+	}
+	transactions := []*types.Transaction{
+		sign(key1, &to, 10),
+		sign(key1, &to, 1),
+		sign(key2, &to, 10),
+		sign(key1, nil, 10),
+	}
+	// Check that subscribers receive the complete transactions.
+	pendingTxs := make(chan []*types.Transaction)
+	sub := api.events.SubscribePendingTxs(pendingTxs)
+	defer sub.Unsubscribe()
+
+	backend.txFeed.Send(core.NewTxsEvent{Txs: transactions})
+	select {
+	case txs := <-pendingTxs:
+		if !reflect.DeepEqual(txs, transactions) {
+			t.Fatalf("pending transactions mismatch")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for pending transactions")
+	}
+
+	tests := []struct {
+		crit PendingTxCriteria
+		want []bool
+	}{
+		{PendingTxCriteria{}, []bool{true, true, true, true}},
+		{PendingTxCriteria{From: []common.Address{addr1}}, []bool{true, true, false, true}},
+		{PendingTxCriteria{From: []common.Address{addr1, addr2}}, []bool{true, true, true, true}},
+		{PendingTxCriteria{To: []common.Address{to}}, []bool{true, true, true, false}},
+		{PendingTxCriteria{MinGasPrice: (*hexutil.Big)(big.NewInt(5))}, []bool{true, false, true, true}},
+		{PendingTxCriteria{From: []common.Address{addr1}, To: []common.Address{to}, MinGasPrice: (*hexutil.Big)(big.NewInt(5))}, []bool{true, false, false, false}},
+	}
+	for i, test := range tests {
+		for j, tx := range transactions {
+			if have := test.crit.matches(signer, tx); have != test.want[j] {
+				t.Errorf("test %d, tx %d: match mismatch: have %v, want %v", i, j, have, test.want[j])
+			}
+		}
+	}
+}
+
+// TestPendingTxSubscription tests that pending transaction subscriptions deliver
+// the hashes or complete transactions matching their criteria over RPC.
+func TestPendingTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false)
+		signer  = types.HomesteadSigner{}
+
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		to      = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+	)
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("odf", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	transactions := make([]*types.Transaction, 3)
+	for i, key := range []*ecdsa.PrivateKey{key1, key2, key1} {
+		tx := types.NewTransaction(uint64(i), to, big.NewInt(int64(i)), 21000, big.NewInt(int64(1+4*i)), nil)
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		transactions[i] = signed
+	}
+	// Subscribe to the hashes of all, the full bodies of all and the full bodies of
+	// the well priced transactions sent by the first account
+	var (
+		ctx      = context.Background()
+		hashes   = make(chan common.Hash, len(transactions))
+		fulls    = make(chan *odfapi.RPCTransaction, len(transactions))
+		filtered = make(chan *odfapi.RPCTransaction, len(transactions))
+	)
+	hashSub, err := client.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		t.Fatalf("failed to subscribe to hashes: %v", err)
+	}
+	defer hashSub.Unsubscribe()
+
+	fullSub, err := client.EthSubscribe(ctx, fulls, "newPendingTransactions", true)
+	if err != nil {
+		t.Fatalf("failed to subscribe to transactions: %v", err)
+	}
+	defer fullSub.Unsubscribe()
+
+	crit := PendingTxCriteria{From: []common.Address{addr1}, MinGasPrice: (*hexutil.Big)(big.NewInt(5))}
+	filteredSub, err := client.EthSubscribe(ctx, filtered, "newPendingTransactions", true, crit)
+	if err != nil {
+		t.Fatalf("failed to subscribe to filtered transactions: %v", err)
+	}
+	defer filteredSub.Unsubscribe()
+
+	time.Sleep(1 * time.Second)
+	backend.txFeed.Send(core.NewTxsEvent{Txs: transactions})
+
+	receive := func(ch chan *odfapi.RPCTransaction, want []*types.Transaction) {
+		t.Helper()
+		for i, wanted := range want {
+			select {
+			case tx := <-ch:
+				if tx.Hash != wanted.Hash() {
+					t.Fatalf("transaction %d mismatch: have %x, want %x", i, tx.Hash, wanted.Hash())
+				}
+				from, _ := types.Sender(signer, wanted)
+				if tx.From != from {
+					t.Fatalf("transaction %d sender mismatch: have %x, want %x", i, tx.From, from)
+				}
+				if uint64(tx.Nonce) != wanted.Nonce() || tx.GasPrice.ToInt().Cmp(wanted.GasPrice()) != 0 || tx.Value.ToInt().Cmp(wanted.Value()) != 0 {
+					t.Fatalf("transaction %d fields mismatch", i)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timeout waiting for transaction %d", i)
+			}
+		}
+		select {
+		case tx := <-ch:
+			t.Fatalf("unexpected transaction %x", tx.Hash)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	for i, tx := range transactions {
+		select {
+		case hash := <-hashes:
+			if hash != tx.Hash() {
+				t.Fatalf("hash %d mismatch: have %x, want %x", i, hash, tx.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for hash %d", i)
+		}
+	}
+	receive(fulls, transactions)
+	receive(filtered, transactions[2:])
+}
+
 // TestLogFilterCreation test whodfer a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
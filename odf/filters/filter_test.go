@@ -223,3 +223,69 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFiltersPrunedHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filtertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := rawdb.NewLevelDBDatabaseWithFreezer(dir, 0, 0, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		backend = &testBackend{db: db}
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+		genesis = core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, odfash.NewFaker(), db, 32, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+	})
+	td := new(big.Int).Set(genesis.Difficulty())
+	for i, block := range chain {
+		td.Add(td, block.Difficulty())
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteTd(db, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteHeadHeaderHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	// Move the old blocks into the freezer and discard the first half of them
+	db.(interface{ Freeze(uint64) }).Freeze(8)
+	if err := db.TruncateTail(16); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	// Ranges reaching into the pruned history must be refused
+	for _, begin := range []int64{0, 15} {
+		_, err := NewRangeFilter(backend, begin, -1, []common.Address{addr}, nil).Logs(context.Background())
+		if perr, ok := err.(*core.PrunedHistoryError); !ok || perr.ErrorCode() != 4444 {
+			t.Errorf("range from %d: expected pruned history error, got %v", begin, err)
+		}
+	}
+	_, err = NewBlockFilter(backend, chain[4].Hash(), []common.Address{addr}, nil).Logs(context.Background())
+	if _, ok := err.(*core.PrunedHistoryError); !ok {
+		t.Errorf("pruned block: expected pruned history error, got %v", err)
+	}
+	// Ranges fully above the pruned tail must still be served
+	logs, err := NewRangeFilter(backend, 16, -1, []common.Address{addr}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter retained history: %v", err)
+	}
+	if len(logs) != 17 {
+		t.Errorf("expected 17 logs, got %d", len(logs))
+	}
+	logs, err = NewBlockFilter(backend, chain[20].Hash(), []common.Address{addr}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter retained block: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("expected 1 log, got %d", len(logs))
+	}
+}
@@ -0,0 +1,403 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	odf "github.com/odf/go-odf"
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/log"
+	"github.com/odf/go-odf/rpc"
+)
+
+const (
+	// maxCursorReorgDepth is the maximum number of blocks between a subscription
+	// cursor and its closest canonical ancestor.
+	maxCursorReorgDepth = 1024
+
+	// maxCursorDistance is the maximum number of blocks a subscription cursor can
+	// be behind the head of the chain.
+	maxCursorDistance = 1024
+
+	// maxPendingEvents is the maximum number of live events buffered while the
+	// history of a resumed subscription is replayed.
+	maxPendingEvents = 4096
+
+	// replayBatchSize is the number of blocks searched for logs at once while
+	// replaying the history of a resumed subscription.
+	replayBatchSize = 1000
+
+	// replayWindowSize is the number of most recently replayed blocks which live
+	// events are checked against, to avoid delivering them twice.
+	replayWindowSize = 256
+)
+
+var (
+	errUnknownCursor  = errors.New("unknown subscription cursor block")
+	errCursorRange    = errors.New("subscription cursor cannot be combined with a block range")
+	errCursorTooOld   = fmt.Errorf("subscription cursor more than %d blocks behind the head", maxCursorDistance)
+	errReplayOverflow = errors.New("too many events while replaying subscription history")
+)
+
+// SubscriptionCursor is the position from which a resumed newHeads or logs
+// subscription continues. Either the number of the first block to deliver or the
+// hash of the last block the client processed can be given. In the latter case, if
+// that block was reorged out of the canonical chain in the meantime, the logs of
+// all non-canonical blocks the client may have seen are retracted with removed
+// notifications before delivery resumes at the first canonical block after them.
+type SubscriptionCursor struct {
+	BlockNumber *rpc.BlockNumber `json:"blockNumber"`
+	BlockHash   *common.Hash     `json:"blockHash"`
+}
+
+// resolveCursor determines the first block to replay for a resumed subscription,
+// along with the headers of the blocks the client has seen which are no longer
+// canonical, newest first. Cursors too far behind the head are rejected.
+func resolveCursor(ctx context.Context, backend Backend, cursor *SubscriptionCursor) (uint64, []*types.Header, error) {
+	start, reorged, err := findCursor(ctx, backend, cursor)
+	if err != nil {
+		return 0, nil, err
+	}
+	head, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+	if head != nil && start+maxCursorDistance < head.Number.Uint64() {
+		return 0, nil, errCursorTooOld
+	}
+	return start, reorged, nil
+}
+
+// findCursor locates the first block to replay for the given cursor, along with
+// the non-canonical headers the client has seen.
+func findCursor(ctx context.Context, backend Backend, cursor *SubscriptionCursor) (uint64, []*types.Header, error) {
+	switch {
+	case cursor.BlockNumber != nil && cursor.BlockHash != nil:
+		return 0, nil, errors.New("subscription cursor can't have both blockNumber and blockHash")
+
+	case cursor.BlockNumber != nil:
+		switch *cursor.BlockNumber {
+		case rpc.PendingBlockNumber:
+			return 0, nil, errors.New("subscriptions can't be resumed from the pending block")
+		case rpc.LatestBlockNumber:
+			head, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+			if err != nil {
+				return 0, nil, err
+			}
+			if head == nil {
+				return 0, nil, nil
+			}
+			return head.Number.Uint64(), nil, nil
+		}
+		return uint64(*cursor.BlockNumber), nil, nil
+
+	case cursor.BlockHash != nil:
+		header, err := backend.HeaderByHash(ctx, *cursor.BlockHash)
+		if err != nil {
+			return 0, nil, err
+		}
+		var reorged []*types.Header
+		for header != nil {
+			canon, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+			if err != nil {
+				return 0, nil, err
+			}
+			if canon != nil && canon.Hash() == header.Hash() {
+				return header.Number.Uint64() + 1, reorged, nil
+			}
+			if len(reorged) == maxCursorReorgDepth {
+				return 0, nil, fmt.Errorf("subscription cursor reorged more than %d blocks deep", maxCursorReorgDepth)
+			}
+			reorged = append(reorged, header)
+			if header, err = backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+				return 0, nil, err
+			}
+		}
+		return 0, nil, errUnknownCursor
+	}
+	return 0, nil, errors.New("empty subscription cursor")
+}
+
+// subscriptionContext returns a context which is canceled once the client
+// unsubscribes or its connection is closed.
+func subscriptionContext(sub *rpc.Subscription, notifier *rpc.Notifier) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		select {
+		case <-sub.Err():
+		case <-notifier.Closed():
+		}
+	}()
+	return ctx
+}
+
+// replayWindow remembers the hashes of the most recently replayed blocks, so live
+// events racing with the replay are not delivered twice.
+type replayWindow struct {
+	hashes  map[uint64]common.Hash
+	highest uint64
+}
+
+func newReplayWindow() *replayWindow {
+	return &replayWindow{hashes: make(map[uint64]common.Hash)}
+}
+
+// add records a replayed block, forgetting blocks which fell out of the window.
+func (w *replayWindow) add(number uint64, hash common.Hash) {
+	w.hashes[number] = hash
+	if number > w.highest {
+		w.highest = number
+	}
+	if len(w.hashes) > 2*replayWindowSize {
+		for n := range w.hashes {
+			if n+replayWindowSize < w.highest {
+				delete(w.hashes, n)
+			}
+		}
+	}
+}
+
+// contains reports whether the given block was replayed.
+func (w *replayWindow) contains(number uint64, hash common.Hash) bool {
+	have, ok := w.hashes[number]
+	return ok && have == hash
+}
+
+// resumeHeads delivers the canonical headers from start up to the current head and
+// then switches over to live headers, until ctx is canceled. An error is returned
+// if the replay fails or too many live headers arrive during it.
+func (api *PublicFilterAPI) resumeHeads(ctx context.Context, start uint64, notify func(*types.Header)) error {
+	headers := make(chan *types.Header)
+	headersSub := api.events.SubscribeNewHeads(headers)
+	defer headersSub.Unsubscribe()
+
+	var (
+		replay   = make(chan *types.Header)
+		done     = make(chan error, 1)
+		replayed = newReplayWindow()
+		pending  []*types.Header // live headers arriving during the replay
+	)
+	go func() { done <- replayHeaders(ctx, api.backend, start, replay) }()
+
+	for {
+		select {
+		case header := <-replay:
+			replayed.add(header.Number.Uint64(), header.Hash())
+			notify(header)
+
+		case err := <-done:
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil // Unsubscribed during the replay
+				}
+				log.Debug("Failed to replay headers for subscription", "start", start, "err", err)
+				return err
+			}
+			for _, header := range pending {
+				if !replayed.contains(header.Number.Uint64(), header.Hash()) {
+					notify(header)
+				}
+			}
+			replay, done, pending = nil, nil, nil
+
+		case header := <-headers:
+			if done != nil {
+				if len(pending) == maxPendingEvents {
+					return errReplayOverflow
+				}
+				pending = append(pending, header)
+			} else {
+				notify(header)
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// replayHeaders sends the canonical headers from start up to the head of the chain
+// to out, following the head until it is reached.
+func replayHeaders(ctx context.Context, backend Backend, start uint64, out chan<- *types.Header) error {
+	number := start
+	for {
+		head, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return err
+		}
+		if head == nil || number > head.Number.Uint64() {
+			return nil
+		}
+		for ; number <= head.Number.Uint64(); number++ {
+			header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if err != nil {
+				return err
+			}
+			if header == nil {
+				return nil // chain was reorged to a shorter one
+			}
+			select {
+			case out <- header:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// resumeLogs retracts the logs of the reorged blocks, delivers the logs matching
+// crit from start up to the current head and then switches over to live logs,
+// until ctx is canceled. An error is returned if the replay fails or too many live
+// logs arrive during it.
+func (api *PublicFilterAPI) resumeLogs(ctx context.Context, crit FilterCriteria, start uint64, reorged []*types.Header, notify func(*types.Log)) error {
+	matchedLogs := make(chan []*types.Log)
+	logsSub, err := api.events.SubscribeLogs(odf.FilterQuery(crit), matchedLogs)
+	if err != nil {
+		return err
+	}
+	defer logsSub.Unsubscribe()
+
+	var (
+		replay   = make(chan []*types.Log)
+		done     = make(chan error, 1)
+		replayed = newReplayWindow()
+		pending  [][]*types.Log // live logs arriving during the replay
+		buffered int            // number of logs in pending
+	)
+	go func() { done <- replayLogs(ctx, api.backend, crit, start, reorged, replay) }()
+
+	for {
+		select {
+		case logs := <-replay:
+			for _, l := range logs {
+				if !l.Removed {
+					replayed.add(l.BlockNumber, l.BlockHash)
+				}
+				notify(l)
+			}
+
+		case err := <-done:
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil // Unsubscribed during the replay
+				}
+				log.Debug("Failed to replay logs for subscription", "start", start, "err", err)
+				return err
+			}
+			for _, logs := range pending {
+				for _, l := range logs {
+					if l.Removed || !replayed.contains(l.BlockNumber, l.BlockHash) {
+						notify(l)
+					}
+				}
+			}
+			replay, done, pending = nil, nil, nil
+
+		case logs := <-matchedLogs:
+			if done != nil {
+				if buffered += len(logs); buffered > maxPendingEvents {
+					return errReplayOverflow
+				}
+				pending = append(pending, logs)
+			} else {
+				for _, l := range logs {
+					notify(l)
+				}
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// replayLogs sends the logs matching crit of the reorged blocks, flagged as removed,
+// followed by the matching logs of the canonical blocks from start up to the head
+// of the chain to out.
+func replayLogs(ctx context.Context, backend Backend, crit FilterCriteria, start uint64, reorged []*types.Header, out chan<- []*types.Log) error {
+	send := func(logs []*types.Log) error {
+		if len(logs) == 0 {
+			return nil
+		}
+		select {
+		case out <- logs:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, header := range reorged {
+		logs, err := removedLogs(ctx, backend, header, crit)
+		if err != nil {
+			return err
+		}
+		if err := send(logs); err != nil {
+			return err
+		}
+	}
+	number := start
+	for {
+		head, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil {
+			return err
+		}
+		if head == nil || number > head.Number.Uint64() {
+			return nil
+		}
+		for number <= head.Number.Uint64() {
+			end := number + replayBatchSize - 1
+			if end > head.Number.Uint64() {
+				end = head.Number.Uint64()
+			}
+			logs, err := NewRangeFilter(backend, int64(number), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+			if err != nil {
+				return err
+			}
+			if err := send(logs); err != nil {
+				return err
+			}
+			number = end + 1
+		}
+	}
+}
+
+// removedLogs returns the logs of the given block matching crit, flagged as removed
+// and in reverse order.
+func removedLogs(ctx context.Context, backend Backend, header *types.Header, crit FilterCriteria) ([]*types.Log, error) {
+	receiptLogs, err := backend.GetLogs(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var logs []*types.Log
+	for _, l := range receiptLogs {
+		logs = append(logs, l...)
+	}
+	logs = filterLogs(logs, nil, nil, crit.Addresses, crit.Topics)
+
+	removed := make([]*types.Log, len(logs))
+	for i, l := range logs {
+		cpy := *l
+		cpy.Removed = true
+		removed[len(logs)-1-i] = &cpy
+	}
+	return removed, nil
+}
@@ -0,0 +1,337 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/consensus/odfash"
+	"github.com/odf/go-odf/core"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/types"
+	"github.com/odf/go-odf/odfdb"
+	"github.com/odf/go-odf/params"
+	"github.com/odf/go-odf/rpc"
+)
+
+// makeResumeChain generates n blocks on top of the genesis, each carrying a
+// single log from addr with the block index and the given salt as topics.
+func makeResumeChain(db odfdb.Database, genesis *types.Block, n int, addr common.Address, salt byte) ([]*types.Block, []types.Receipts) {
+	return core.GenerateChain(params.TestChainConfig, genesis, odfash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{salt})
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{{
+			Address: addr,
+			Topics:  []common.Hash{common.BigToHash(big.NewInt(int64(i))), {salt}},
+		}}
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.Address{salt}, big.NewInt(1), 1, big.NewInt(1), nil))
+	})
+}
+
+// writeResumeChain stores the given blocks and receipts, optionally marking them
+// as the canonical chain.
+func writeResumeChain(db odfdb.Database, blocks []*types.Block, receipts []types.Receipts, canonical bool) {
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		if canonical {
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+		}
+	}
+}
+
+func TestResumeHeads(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &testBackend{db: db}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		addr      = common.Address{0xaa}
+		chain, rs = makeResumeChain(db, genesis, 6, addr, 1)
+	)
+	writeResumeChain(db, chain[:5], rs[:5], true)
+
+	start, reorged, err := resolveCursor(context.Background(), backend, &SubscriptionCursor{BlockHash: &chain[1].Header().ParentHash})
+	if err != nil {
+		t.Fatalf("failed to resolve cursor: %v", err)
+	}
+	if start != 2 || len(reorged) != 0 {
+		t.Fatalf("cursor mismatch: have start %d, %d reorged, want start 2, 0 reorged", start, len(reorged))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	headers := make(chan *types.Header)
+	go api.resumeHeads(ctx, start, func(h *types.Header) { headers <- h })
+
+	for _, block := range chain[1:5] {
+		if err := expectHeader(headers, block); err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+	}
+	backend.chainFeed.Send(core.ChainEvent{Block: chain[5], Hash: chain[5].Hash()})
+	if err := expectHeader(headers, chain[5]); err != nil {
+		t.Fatalf("live: %v", err)
+	}
+}
+
+func TestResumeLogsAfterReorg(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &testBackend{db: db}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		addr      = common.Address{0xaa}
+		chain, rs = makeResumeChain(db, genesis, 4, addr, 1)
+		side, srs = makeResumeChain(db, genesis, 2, addr, 2)
+	)
+	writeResumeChain(db, side, srs, false)
+	writeResumeChain(db, chain[:3], rs[:3], true)
+
+	// The client last processed the head of the side chain, which was reorged out.
+	cursor := &SubscriptionCursor{BlockHash: new(common.Hash)}
+	*cursor.BlockHash = side[1].Hash()
+
+	start, reorged, err := resolveCursor(context.Background(), backend, cursor)
+	if err != nil {
+		t.Fatalf("failed to resolve cursor: %v", err)
+	}
+	if start != 1 || len(reorged) != 2 {
+		t.Fatalf("cursor mismatch: have start %d, %d reorged, want start 1, 2 reorged", start, len(reorged))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logs := make(chan *types.Log)
+	crit := FilterCriteria{Addresses: []common.Address{addr}}
+	go api.resumeLogs(ctx, crit, start, reorged, func(l *types.Log) { logs <- l })
+
+	want := []struct {
+		block   *types.Block
+		removed bool
+	}{
+		{side[1], true}, {side[0], true}, {chain[0], false}, {chain[1], false}, {chain[2], false},
+	}
+	for i, w := range want {
+		if err := expectLog(logs, w.block, w.removed); err != nil {
+			t.Fatalf("replay log %d: %v", i, err)
+		}
+	}
+	live := *rs[3][0].Logs[0]
+	live.BlockNumber, live.BlockHash = chain[3].NumberU64(), chain[3].Hash()
+	backend.logsFeed.Send([]*types.Log{&live})
+	if err := expectLog(logs, chain[3], false); err != nil {
+		t.Fatalf("live: %v", err)
+	}
+}
+
+func TestResolveCursor(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &testBackend{db: db}
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, rs = makeResumeChain(db, genesis, 3, common.Address{0xaa}, 1)
+		number    = rpc.BlockNumber(2)
+		latest    = rpc.LatestBlockNumber
+		pending   = rpc.PendingBlockNumber
+		unknown   = common.Hash{0xff}
+	)
+	writeResumeChain(db, chain, rs, true)
+
+	tests := []struct {
+		cursor SubscriptionCursor
+		start  uint64
+		fail   bool
+	}{
+		{cursor: SubscriptionCursor{BlockNumber: &number}, start: 2},
+		{cursor: SubscriptionCursor{BlockNumber: &latest}, start: 3},
+		{cursor: SubscriptionCursor{BlockNumber: &pending}, fail: true},
+		{cursor: SubscriptionCursor{BlockHash: &unknown}, fail: true},
+		{cursor: SubscriptionCursor{BlockNumber: &number, BlockHash: &unknown}, fail: true},
+		{cursor: SubscriptionCursor{}, fail: true},
+	}
+	for i, test := range tests {
+		start, _, err := resolveCursor(context.Background(), backend, &test.cursor)
+		if test.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		} else if start != test.start {
+			t.Errorf("test %d: start mismatch: have %d, want %d", i, start, test.start)
+		}
+	}
+}
+
+func expectHeader(headers <-chan *types.Header, block *types.Block) error {
+	select {
+	case h := <-headers:
+		if h.Hash() != block.Hash() {
+			return fmt.Errorf("header mismatch: have #%d %x, want #%d %x", h.Number, h.Hash(), block.Number(), block.Hash())
+		}
+		return nil
+	case <-time.After(time.Second):
+		return fmt.Errorf("timeout waiting for header #%d", block.Number())
+	}
+}
+
+func expectLog(logs <-chan *types.Log, block *types.Block, removed bool) error {
+	select {
+	case l := <-logs:
+		if l.BlockHash != block.Hash() || l.Removed != removed {
+			return fmt.Errorf("log mismatch: have block %x removed %v, want block %x removed %v", l.BlockHash, l.Removed, block.Hash(), removed)
+		}
+		return nil
+	case <-time.After(time.Second):
+		return fmt.Errorf("timeout waiting for log of block #%d", block.Number())
+	}
+}
+
+func TestResolveCursorDistance(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &testBackend{db: db}
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, rs = makeResumeChain(db, genesis, maxCursorDistance+6, common.Address{0xaa}, 1)
+		old       = rpc.BlockNumber(5)
+		recent    = rpc.BlockNumber(6)
+		oldHash   = chain[3].Hash()
+		newHash   = chain[4].Hash()
+	)
+	writeResumeChain(db, chain, rs, true)
+
+	tests := []struct {
+		cursor SubscriptionCursor
+		fail   bool
+	}{
+		{cursor: SubscriptionCursor{BlockNumber: &old}, fail: true},
+		{cursor: SubscriptionCursor{BlockNumber: &recent}},
+		{cursor: SubscriptionCursor{BlockHash: &oldHash}, fail: true},
+		{cursor: SubscriptionCursor{BlockHash: &newHash}},
+	}
+	for i, test := range tests {
+		_, _, err := resolveCursor(context.Background(), backend, &test.cursor)
+		if test.fail && err != errCursorTooOld {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, errCursorTooOld)
+		}
+		if !test.fail && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+// replayBackend wraps a test backend, holding back the lookups of canonical
+// headers until released and failing the lookup of a given block.
+type replayBackend struct {
+	*testBackend
+	gate chan struct{} // if set, header lookups by number wait for it to be closed
+	fail uint64        // if set, number of the block whose lookup fails
+}
+
+func (b *replayBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number >= 0 && b.gate != nil {
+		select {
+		case <-b.gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if b.fail != 0 && uint64(number) == b.fail {
+		return nil, errors.New("header lookup failed")
+	}
+	return b.testBackend.HeaderByNumber(ctx, number)
+}
+
+func TestResumeOverflow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &replayBackend{testBackend: &testBackend{db: db}, gate: make(chan struct{})}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, rs = makeResumeChain(db, genesis, 3, common.Address{0xaa}, 1)
+	)
+	writeResumeChain(db, chain, rs, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- api.resumeHeads(ctx, 1, func(h *types.Header) { t.Errorf("unexpected header #%d", h.Number) })
+	}()
+	// Flood the subscription with live headers while the replay is stuck
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-errc:
+			if err != errReplayOverflow {
+				t.Fatalf("error mismatch: have %v, want %v", err, errReplayOverflow)
+			}
+			return
+		case <-timeout:
+			t.Fatal("subscription not dropped on overflow")
+		default:
+			backend.chainFeed.Send(core.ChainEvent{Block: chain[2], Hash: chain[2].Hash()})
+		}
+	}
+}
+
+func TestResumeReplayFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		backend   = &replayBackend{testBackend: &testBackend{db: db}, fail: 2}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		addr      = common.Address{0xaa}
+		chain, rs = makeResumeChain(db, genesis, 3, addr, 1)
+	)
+	writeResumeChain(db, chain, rs, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	headers := make(chan *types.Header, 3)
+	if err := api.resumeHeads(ctx, 1, func(h *types.Header) { headers <- h }); err == nil {
+		t.Fatal("header replay failure not reported")
+	}
+	if err := expectHeader(headers, chain[0]); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	logs := make(chan *types.Log, 3)
+	if err := api.resumeLogs(ctx, FilterCriteria{Addresses: []common.Address{addr}}, 1, nil, func(l *types.Log) { logs <- l }); err == nil {
+		t.Fatal("log replay failure not reported")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/odf/go-odf/params"
+	"github.com/odf/go-odf/rpc"
+)
+
+func TestFeeHistory(t *testing.T) {
+	var cases = []struct {
+		maxHeader, maxBlock int
+		count               int
+		last                rpc.BlockNumber
+		percent             []float64
+		expFirst            uint64
+		expCount            int
+		expErr              error
+	}{
+		{1000, 1000, 10, 30, nil, 21, 10, nil},
+		{1000, 1000, 10, 30, []float64{0, 10}, 21, 10, nil},
+		{1000, 1000, 10, 30, []float64{20, 10}, 0, 0, errInvalidPercentile},
+		{1000, 1000, 10, 30, []float64{101}, 0, 0, errInvalidPercentile},
+		{1000, 1000, 1000000000, 30, nil, 0, 31, nil},
+		{1000, 1000, 1000000000, rpc.LatestBlockNumber, nil, 0, 33, nil},
+		{1000, 1000, 10, 40, nil, 0, 0, errRequestBeyondHead},
+		{20, 2, 100, rpc.LatestBlockNumber, nil, 13, 20, nil},
+		{20, 2, 100, rpc.LatestBlockNumber, []float64{0, 10}, 31, 2, nil},
+		{20, 2, 100, 32, []float64{0, 10}, 31, 2, nil},
+		{1000, 1000, 1, rpc.PendingBlockNumber, nil, 32, 1, nil},
+		{1000, 1000, 0, rpc.LatestBlockNumber, nil, 0, 0, nil},
+	}
+	for i, c := range cases {
+		config := Config{
+			MaxHeaderHistory: c.maxHeader,
+			MaxBlockHistory:  c.maxBlock,
+		}
+		backend := newTestBackend(t)
+		oracle := NewOracle(backend, config)
+
+		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
+
+		expReward := c.expCount
+		if len(c.percent) == 0 {
+			expReward = 0
+		}
+		expBaseFee := c.expCount
+		if expBaseFee != 0 {
+			expBaseFee++
+		}
+		if first.Uint64() != c.expFirst {
+			t.Fatalf("Test case %d: first block mismatch, want %d, got %d", i, c.expFirst, first)
+		}
+		if len(reward) != expReward {
+			t.Fatalf("Test case %d: reward array length mismatch, want %d, got %d", i, expReward, len(reward))
+		}
+		if len(baseFee) != expBaseFee {
+			t.Fatalf("Test case %d: baseFee array length mismatch, want %d, got %d", i, expBaseFee, len(baseFee))
+		}
+		if len(ratio) != c.expCount {
+			t.Fatalf("Test case %d: gasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(ratio))
+		}
+		if err != c.expErr && !errors.Is(err, c.expErr) {
+			t.Fatalf("Test case %d: error mismatch, want %v, got %v", i, c.expErr, err)
+		}
+	}
+}
+
+func TestFeeHistoryRewards(t *testing.T) {
+	backend := newTestBackend(t)
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 1000, MaxBlockHistory: 1000})
+
+	// Every block contains a single transaction paying (number) gwei per gas
+	_, reward, _, _, err := oracle.FeeHistory(context.Background(), 3, 30, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatalf("Failed to retrieve fee history: %v", err)
+	}
+	for i, rewards := range reward {
+		expect := big.NewInt(int64(28+i) * params.GWei)
+		for j, r := range rewards {
+			if r.Cmp(expect) != 0 {
+				t.Fatalf("Block %d percentile %d: reward mismatch, want %d, got %d", 28+i, j, expect, r)
+			}
+		}
+	}
+	// Querying the same range again must be served from the cache with identical results
+	_, cached, _, _, err := oracle.FeeHistory(context.Background(), 3, 30, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatalf("Failed to retrieve cached fee history: %v", err)
+	}
+	for i := range reward {
+		for j := range reward[i] {
+			if reward[i][j].Cmp(cached[i][j]) != 0 {
+				t.Fatalf("Cached reward mismatch at %d/%d: want %d, got %d", i, j, reward[i][j], cached[i][j])
+			}
+		}
+	}
+}
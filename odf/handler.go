@@ -33,6 +33,7 @@ import (
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/odf/downloader"
 	"github.com/odf/go-odf/odf/fetcher"
+	"github.com/odf/go-odf/odf/protocols/snap"
 	"github.com/odf/go-odf/odfdb"
 	"github.com/odf/go-odf/event"
 	"github.com/odf/go-odf/log"
@@ -65,6 +66,7 @@ type ProtocolManager struct {
 	forkFilter forkid.Filter // Fork ID filter, constant across the lifetime of the node
 
 	fastSync  uint32 // Flag whodfer fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whodfer snap sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whodfer we're considered synchronised (enables transaction processing)
 
 	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
@@ -80,6 +82,9 @@ type ProtocolManager struct {
 	txFetcher    *fetcher.TxFetcher
 	peers        *peerSet
 
+	snapPeers    map[string]*snap.Peer // Peers connected on the `snap` protocol
+	snapPeerLock sync.RWMutex           // Protects the snap peer set
+
 	eventMux      *event.TypeMux
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
@@ -111,6 +116,7 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		blockchain: blockchain,
 		chaindb:    chaindb,
 		peers:      newPeerSet(),
+		snapPeers:  make(map[string]*snap.Peer),
 		whitelist:  whitelist,
 		txsyncCh:   make(chan *txsync),
 		quitSync:   make(chan struct{}),
@@ -137,6 +143,9 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 		} else {
 			// If fast sync was requested and our database is empty, grant it
 			manager.fastSync = uint32(1)
+			if mode == downloader.SnapSync {
+				manager.snapSync = uint32(1)
+			}
 		}
 	}
 
@@ -215,7 +224,7 @@ func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 		Version: version,
 		Length:  length,
 		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-			return pm.runPeer(pm.newPeer(int(version), p, rw, pm.txpool.Get))
+			return pm.runPeer(pm.newPeer(int(version), p, rw, pm.getPublicTx))
 		},
 		NodeInfo: func() interface{} {
 			return pm.NodeInfo()
@@ -229,6 +238,53 @@ func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 	}
 }
 
+// Chain retrieves the blockchain object to serve `snap` data from.
+func (pm *ProtocolManager) Chain() *core.BlockChain {
+	return pm.blockchain
+}
+
+// RunPeer is invoked when a peer joins on the `snap` protocol. The peer is
+// registered as a state data source with the downloader for the lifetime of
+// the connection.
+func (pm *ProtocolManager) RunPeer(peer *snap.Peer, handler snap.Handler) error {
+	pm.snapPeerLock.Lock()
+	if _, ok := pm.snapPeers[peer.ID()]; ok {
+		pm.snapPeerLock.Unlock()
+		return errAlreadyRegistered
+	}
+	pm.snapPeers[peer.ID()] = peer
+	pm.snapPeerLock.Unlock()
+
+	defer func() {
+		pm.snapPeerLock.Lock()
+		delete(pm.snapPeers, peer.ID())
+		pm.snapPeerLock.Unlock()
+	}()
+	if err := pm.downloader.SnapSyncer.Register(peer); err != nil {
+		return err
+	}
+	defer pm.downloader.SnapSyncer.Unregister(peer.ID())
+
+	return handler(peer)
+}
+
+// PeerInfo retrieves all known `snap` information about a peer.
+func (pm *ProtocolManager) PeerInfo(id enode.ID) interface{} {
+	pm.snapPeerLock.RLock()
+	defer pm.snapPeerLock.RUnlock()
+
+	if p := pm.snapPeers[id.String()]; p != nil {
+		return p.Info()
+	}
+	return nil
+}
+
+// Handle is invoked from a `snap` peer's message handler when it receives a
+// data packet that the protocol handler itself does not consume.
+func (pm *ProtocolManager) Handle(peer *snap.Peer, packet snap.Packet) error {
+	return pm.downloader.DeliverSnapPacket(peer, packet)
+}
+
 func (pm *ProtocolManager) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := pm.peers.Peer(id)
@@ -778,7 +834,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested transaction, skipping if unknown to us
-			tx := pm.txpool.Get(hash)
+			tx := pm.getPublicTx(hash)
 			if tx == nil {
 				continue
 			}
@@ -858,6 +914,7 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 		txset = make(map[*peer][]common.Hash)
 		annos = make(map[*peer][]common.Hash)
 	)
+	txs = pm.publicTxs(txs)
 	// Broadcast transactions to a batch of peers not knowing about it
 	if propagate {
 		for _, tx := range txs {
@@ -891,6 +948,27 @@ func (pm *ProtocolManager) BroadcastTransactions(txs types.Transactions, propaga
 	}
 }
 
+// getPublicTx retrieves a transaction from the pool for remote peers, hiding the
+// privately submitted ones.
+func (pm *ProtocolManager) getPublicTx(hash common.Hash) *types.Transaction {
+	if pm.txpool.IsPrivate(hash) {
+		return nil
+	}
+	return pm.txpool.Get(hash)
+}
+
+// publicTxs filters out the privately submitted transactions, which must never
+// be propagated or announced to remote peers.
+func (pm *ProtocolManager) publicTxs(txs types.Transactions) types.Transactions {
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if !pm.txpool.IsPrivate(tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	defer pm.wg.Done()
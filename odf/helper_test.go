@@ -90,9 +90,10 @@ func newTestProtocolManagerMust(t *testing.T, mode downloader.SyncMode, blocks i
 
 // testTxPool is a fake, helper transaction pool for testing purposes
 type testTxPool struct {
-	txFeed event.Feed
-	pool   map[common.Hash]*types.Transaction // Hash map of collected transactions
-	added  chan<- []*types.Transaction        // Notification channel for new transactions
+	txFeed  event.Feed
+	pool    map[common.Hash]*types.Transaction // Hash map of collected transactions
+	private map[common.Hash]bool               // Hashes of the privately submitted transactions
+	added   chan<- []*types.Transaction        // Notification channel for new transactions
 
 	lock sync.RWMutex // Protects the transaction pool
 }
@@ -131,6 +132,30 @@ func (p *testTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// addPrivate appends a batch of privately submitted transactions to the pool.
+// Contrary to the real pool, they are announced like remote ones to exercise the
+// filtering of the handler.
+func (p *testTxPool) addPrivate(txs []*types.Transaction) {
+	p.lock.Lock()
+	if p.private == nil {
+		p.private = make(map[common.Hash]bool)
+	}
+	for _, tx := range txs {
+		p.private[tx.Hash()] = true
+	}
+	p.lock.Unlock()
+
+	p.AddRemotes(txs)
+}
+
+// IsPrivate returns whodfer a transaction was submitted privately.
+func (p *testTxPool) IsPrivate(hash common.Hash) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.private[hash]
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
 	p.lock.RLock()
@@ -173,7 +198,7 @@ func newTestPeer(name string, version int, pm *ProtocolManager, shake bool) (*te
 	// Start the peer on a new thread
 	var id enode.ID
 	rand.Read(id[:])
-	peer := pm.newPeer(version, p2p.NewPeer(id, name, nil), net, pm.txpool.Get)
+	peer := pm.newPeer(version, p2p.NewPeer(id, name, nil), net, pm.getPublicTx)
 	errc := make(chan error, 1)
 	go func() { errc <- pm.runPeer(peer) }()
 	tp := &testPeer{app: app, net: net, peer: peer}
@@ -118,6 +118,10 @@ type txPool interface {
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
 
+	// IsPrivate should return whodfer the transaction with the given hash
+	// was submitted privately and must not be propagated.
+	IsPrivate(hash common.Hash) bool
+
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
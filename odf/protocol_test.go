@@ -367,6 +367,109 @@ func testSendTransactions(t *testing.T, protocol int) {
 	wg.Wait()
 }
 
+func TestPrivateTransactions64(t *testing.T) { testPrivateTransactions(t, 64) }
+func TestPrivateTransactions65(t *testing.T) { testPrivateTransactions(t, 65) }
+
+// Tests that privately submitted transactions are never sent to remote peers,
+// neither on the initial transaction sync, nor broadcast, announced or served on
+// request.
+func testPrivateTransactions(t *testing.T, protocol int) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	pool := pm.txpool.(*testTxPool)
+	split := func(from, to int) (public, private []*types.Transaction) {
+		for nonce := from; nonce < to; nonce++ {
+			tx := newTestTransaction(testAccount, uint64(nonce), 0)
+			if nonce%2 == 0 {
+				public = append(public, tx)
+			} else {
+				private = append(private, tx)
+			}
+		}
+		return public, private
+	}
+	// Fill the pool with public and private transactions before connecting
+	public, private := split(0, 20)
+	pool.addPrivate(private)
+	pool.AddRemotes(public)
+	time.Sleep(100 * time.Millisecond) // Wait until new tx even gets out of the system (lame)
+
+	p, _ := newTestPeer("peer", protocol, pm, true)
+	defer p.close()
+
+	// expect reads broadcasts and announcements until all the given transactions
+	// were seen, failing on any other one
+	expect := func(txs []*types.Transaction) {
+		want := make(map[common.Hash]bool)
+		for _, tx := range txs {
+			want[tx.Hash()] = true
+		}
+		for len(want) > 0 {
+			msg, err := p.app.ReadMsg()
+			if err != nil {
+				t.Fatalf("read error: %v", err)
+			}
+			var hashes []common.Hash
+			switch msg.Code {
+			case TransactionMsg:
+				var txs []*types.Transaction
+				if err := msg.Decode(&txs); err != nil {
+					t.Fatalf("failed to decode transactions: %v", err)
+				}
+				for _, tx := range txs {
+					hashes = append(hashes, tx.Hash())
+				}
+			case NewPooledTransactionHashesMsg:
+				if err := msg.Decode(&hashes); err != nil {
+					t.Fatalf("failed to decode announcement: %v", err)
+				}
+			default:
+				t.Fatalf("unexpected message code %d", msg.Code)
+			}
+			for _, hash := range hashes {
+				if !want[hash] {
+					t.Fatalf("unexpected transaction %x", hash)
+				}
+				delete(want, hash)
+			}
+		}
+	}
+	expect(public)
+
+	// Add more transactions, the private ones being part of the pool events too,
+	// and finish with a public one to catch any late private broadcast
+	public, private = split(20, 40)
+	pool.addPrivate(private)
+	pool.AddRemotes(public)
+	expect(public)
+
+	sentinel := newTestTransaction(testAccount, 40, 0)
+	pool.AddRemotes([]*types.Transaction{sentinel})
+	expect([]*types.Transaction{sentinel})
+
+	// Private transactions must not be served to explicit requests either
+	if protocol >= odf65 {
+		if err := p2p.Send(p.app, GetPooledTransactionsMsg, []common.Hash{private[0].Hash(), public[0].Hash()}); err != nil {
+			t.Fatalf("failed to request transactions: %v", err)
+		}
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		if msg.Code != PooledTransactionsMsg {
+			t.Fatalf("unexpected message code %d", msg.Code)
+		}
+		var txs []*types.Transaction
+		if err := msg.Decode(&txs); err != nil {
+			t.Fatalf("failed to decode transactions: %v", err)
+		}
+		if len(txs) != 1 || txs[0].Hash() != public[0].Hash() {
+			t.Fatalf("served transactions mismatch: have %d, want only the public one", len(txs))
+		}
+	}
+}
+
 func TestTransactionPropagation(t *testing.T)  { testSyncTransaction(t, true) }
 func TestTransactionAnnouncement(t *testing.T) { testSyncTransaction(t, false) }
 
@@ -0,0 +1,500 @@
+// Copyright 2020 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/core/rawdb"
+	"github.com/odf/go-odf/core/state"
+	"github.com/odf/go-odf/crypto"
+	"github.com/odf/go-odf/light"
+	"github.com/odf/go-odf/log"
+	"github.com/odf/go-odf/rlp"
+	"github.com/odf/go-odf/trie"
+)
+
+// kv is a single trie entry used by the test peers to serve ranges.
+type kv struct {
+	k, v []byte
+}
+
+// entrySlice implements sort.Interface to allow sorting a list of kvs by key.
+type entrySlice []*kv
+
+func (p entrySlice) Len() int           { return len(p) }
+func (p entrySlice) Less(i, j int) bool { return bytes.Compare(p[i].k, p[j].k) < 0 }
+func (p entrySlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// testPeer is an in-process snap peer serving state data straight out of a set
+// of in-memory tries, delivering the responses directly to the remote syncer.
+type testPeer struct {
+	id     string
+	test   *testing.T
+	remote *Syncer
+	logger log.Logger
+
+	accountTrie   *trie.Trie
+	accountValues entrySlice
+	storageTries  map[common.Hash]*trie.Trie
+	storageValues map[common.Hash]entrySlice
+
+	lock     sync.Mutex // Serializes trie access, node resolution isn't thread safe
+	cancelCh chan struct{}
+}
+
+func newTestPeer(id string, t *testing.T, cancelCh chan struct{}) *testPeer {
+	return &testPeer{
+		id:       id,
+		test:     t,
+		logger:   log.New("id", id),
+		cancelCh: cancelCh,
+	}
+}
+
+func (t *testPeer) ID() string      { return t.id }
+func (t *testPeer) Log() log.Logger { return t.logger }
+
+func (t *testPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	go func() {
+		t.lock.Lock()
+		keys, vals, proofs := t.serveAccountRange(origin, limit, bytes)
+		t.lock.Unlock()
+
+		if err := t.remote.OnAccounts(t, id, keys, vals, proofs); err != nil {
+			t.test.Errorf("Remote side rejected our delivery: %v", err)
+			t.cancel()
+		}
+	}()
+	return nil
+}
+
+func (t *testPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	go func() {
+		t.lock.Lock()
+		hashes, slots, proofs := t.serveStorageRanges(accounts, origin, limit, bytes)
+		t.lock.Unlock()
+
+		if err := t.remote.OnStorage(t, id, hashes, slots, proofs); err != nil {
+			t.test.Errorf("Remote side rejected our delivery: %v", err)
+			t.cancel()
+		}
+	}()
+	return nil
+}
+
+func (t *testPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	t.test.Errorf("Unexpected bytecode request for %d hashes", len(hashes))
+	t.cancel()
+	return nil
+}
+
+func (t *testPeer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error {
+	go func() {
+		t.lock.Lock()
+		var nodes [][]byte
+		for _, pathset := range paths {
+			switch len(pathset) {
+			case 1:
+				blob, _, err := t.accountTrie.TryGetNode(pathset[0])
+				if err != nil {
+					t.test.Errorf("Failed to retrieve account node: %v", err)
+					continue
+				}
+				nodes = append(nodes, blob)
+			default:
+				stTrie := t.storageTries[common.BytesToHash(pathset[0])]
+				for _, path := range pathset[1:] {
+					blob, _, err := stTrie.TryGetNode(path)
+					if err != nil {
+						t.test.Errorf("Failed to retrieve storage node: %v", err)
+						continue
+					}
+					nodes = append(nodes, blob)
+				}
+			}
+		}
+		t.lock.Unlock()
+
+		if err := t.remote.OnTrieNodes(t, id, nodes); err != nil {
+			t.test.Errorf("Remote side rejected our delivery: %v", err)
+			t.cancel()
+		}
+	}()
+	return nil
+}
+
+// cancel aborts the sync, tolerating being called from multiple failing
+// deliveries concurrently.
+func (t *testPeer) cancel() {
+	select {
+	case <-t.cancelCh:
+	default:
+		close(t.cancelCh)
+	}
+}
+
+// serveAccountRange gathers the accounts in the requested range along with
+// the edge proofs, capping the response at the requested size.
+func (t *testPeer) serveAccountRange(origin, limit common.Hash, cap uint64) ([]common.Hash, [][]byte, [][]byte) {
+	var (
+		keys []common.Hash
+		vals [][]byte
+		size uint64
+	)
+	for _, entry := range t.accountValues {
+		if size > cap {
+			break
+		}
+		if bytes.Compare(origin[:], entry.k) <= 0 {
+			keys = append(keys, common.BytesToHash(entry.k))
+			vals = append(vals, entry.v)
+			size += uint64(32 + len(entry.v))
+		}
+		// If we've exceeded the request threshold, abort
+		if bytes.Compare(entry.k, limit[:]) >= 0 {
+			break
+		}
+	}
+	// Unless we send the entire trie, we need to supply proofs
+	proof := light.NewNodeSet()
+	if err := t.accountTrie.Prove(origin[:], 0, proof); err != nil {
+		t.test.Errorf("Could not prove origin %x: %v", origin, err)
+	}
+	if len(keys) > 0 {
+		last := keys[len(keys)-1]
+		if err := t.accountTrie.Prove(last[:], 0, proof); err != nil {
+			t.test.Errorf("Could not prove last %x: %v", last, err)
+		}
+	}
+	var proofs [][]byte
+	for _, node := range proof.NodeList() {
+		proofs = append(proofs, node)
+	}
+	return keys, vals, proofs
+}
+
+// serveStorageRanges gathers the storage slots of the requested accounts,
+// attaching proofs only if the last range is partial.
+func (t *testPeer) serveStorageRanges(accounts []common.Hash, bOrigin, bLimit []byte, max uint64) ([][]common.Hash, [][][]byte, [][]byte) {
+	var (
+		size   uint64
+		hashes [][]common.Hash
+		slots  [][][]byte
+		proofs [][]byte
+	)
+	limit := maxHash
+	if len(bLimit) > 0 {
+		limit = common.BytesToHash(bLimit)
+	}
+	for i, account := range accounts {
+		// If we've exceeded the requested data limit, abort without opening
+		// a new storage range
+		if size >= max {
+			break
+		}
+		var origin common.Hash
+		if i == 0 && len(bOrigin) > 0 {
+			origin = common.BytesToHash(bOrigin)
+		}
+		var (
+			keys  []common.Hash
+			vals  [][]byte
+			abort bool
+		)
+		for _, entry := range t.storageValues[account] {
+			if size >= max {
+				abort = true
+				break
+			}
+			if bytes.Compare(entry.k, origin[:]) < 0 {
+				continue
+			}
+			keys = append(keys, common.BytesToHash(entry.k))
+			vals = append(vals, entry.v)
+			size += uint64(32 + len(entry.v))
+			if bytes.Compare(entry.k, limit[:]) >= 0 {
+				break
+			}
+		}
+		hashes = append(hashes, keys)
+		slots = append(slots, vals)
+
+		// Generate the Merkle proofs for the first and last storage slot, but
+		// only if the response was capped or a particular origin was requested.
+		if origin != (common.Hash{}) || abort {
+			proof := light.NewNodeSet()
+			stTrie := t.storageTries[account]
+			if err := stTrie.Prove(origin[:], 0, proof); err != nil {
+				t.test.Errorf("Could not prove origin %x: %v", origin, err)
+			}
+			if len(keys) > 0 {
+				last := keys[len(keys)-1]
+				if err := stTrie.Prove(last[:], 0, proof); err != nil {
+					t.test.Errorf("Could not prove last %x: %v", last, err)
+				}
+			}
+			for _, node := range proof.NodeList() {
+				proofs = append(proofs, node)
+			}
+			break
+		}
+	}
+	return hashes, slots, proofs
+}
+
+// key32 returns a 32 byte key whose first 8 bytes are the little endian
+// representation of i.
+func key32(i uint64) []byte {
+	key := make([]byte, 32)
+	binary.LittleEndian.PutUint64(key, i)
+	return key
+}
+
+// makeAccountTrieNoStorage spits out a trie, along with the leafs.
+func makeAccountTrieNoStorage(n int) (*trie.Trie, entrySlice) {
+	db := trie.NewDatabase(rawdb.NewMemoryDatabase())
+	accTrie, _ := trie.New(common.Hash{}, db)
+	var entries entrySlice
+	for i := uint64(1); i <= uint64(n); i++ {
+		value, _ := rlp.EncodeToBytes(state.Account{
+			Nonce:    i,
+			Balance:  big.NewInt(int64(i)),
+			Root:     emptyRoot,
+			CodeHash: emptyCode[:],
+		})
+		key := key32(i)
+		elem := &kv{key, value}
+		accTrie.Update(elem.k, elem.v)
+		entries = append(entries, elem)
+	}
+	sort.Sort(entries)
+	accTrie.Commit(nil)
+	return accTrie, entries
+}
+
+// makeAccountTrieWithStorage spits out a trie, along with the leafs, with every
+// account sharing the same storage content.
+func makeAccountTrieWithStorage(accounts, slots int) (*trie.Trie, entrySlice, map[common.Hash]*trie.Trie, map[common.Hash]entrySlice) {
+	var (
+		db             = trie.NewDatabase(rawdb.NewMemoryDatabase())
+		accTrie, _     = trie.New(common.Hash{}, db)
+		entries        entrySlice
+		storageTries   = make(map[common.Hash]*trie.Trie)
+		storageEntries = make(map[common.Hash]entrySlice)
+	)
+	// Make a storage trie which we reuse for the whole lot
+	stTrie, stEntries := makeStorageTrie(slots, db)
+	stRoot := stTrie.Hash()
+
+	// Create n accounts in the trie
+	for i := uint64(1); i <= uint64(accounts); i++ {
+		key := key32(i)
+		value, _ := rlp.EncodeToBytes(state.Account{
+			Nonce:    i,
+			Balance:  big.NewInt(int64(i)),
+			Root:     stRoot,
+			CodeHash: emptyCode[:],
+		})
+		elem := &kv{key, value}
+		accTrie.Update(elem.k, elem.v)
+		entries = append(entries, elem)
+
+		storageTries[common.BytesToHash(key)] = stTrie
+		storageEntries[common.BytesToHash(key)] = stEntries
+	}
+	sort.Sort(entries)
+	stTrie.Commit(nil)
+	accTrie.Commit(nil)
+	return accTrie, entries, storageTries, storageEntries
+}
+
+// makeStorageTrie fills a storage trie with n items, returning the trie and
+// the sorted entries.
+func makeStorageTrie(n int, db *trie.Database) (*trie.Trie, entrySlice) {
+	stTrie, _ := trie.New(common.Hash{}, db)
+	var entries entrySlice
+	for i := uint64(1); i <= uint64(n); i++ {
+		// store 'i' at slot 'i'
+		slotValue := key32(i)
+		rlpSlotValue, _ := rlp.EncodeToBytes(common.TrimLeftZeroes(slotValue[:]))
+
+		slotKey := key32(i)
+		key := crypto.Keccak256Hash(slotKey[:])
+
+		elem := &kv{key[:], rlpSlotValue}
+		stTrie.Update(elem.k, elem.v)
+		entries = append(entries, elem)
+	}
+	sort.Sort(entries)
+	stTrie.Commit(nil)
+	return stTrie, entries
+}
+
+// verifyTrie checks that the synced account and storage tries are complete.
+func verifyTrie(db *trie.Database, root common.Hash, t *testing.T) {
+	t.Helper()
+
+	accTrie, err := trie.New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, slots := 0, 0
+	accIt := trie.NewIterator(accTrie.NodeIterator(nil))
+	for accIt.Next() {
+		var acc state.Account
+		if err := rlp.DecodeBytes(accIt.Value, &acc); err != nil {
+			t.Fatalf("invalid account encountered during snapshot creation: %v", err)
+		}
+		accounts++
+		if acc.Root != emptyRoot {
+			storeTrie, err := trie.New(acc.Root, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				slots++
+			}
+			if err := storeIt.Err; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := accIt.Err; err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("accounts: %d, slots: %d", accounts, slots)
+}
+
+// runSync syncs the given root against the given peers, failing the test on
+// error or if the sync does not finish in a reasonable time.
+func runSync(t *testing.T, syncer *Syncer, root common.Hash, cancel chan struct{}) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- syncer.Sync(root, cancel) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		close(cancel)
+		t.Fatalf("sync timed out")
+	}
+}
+
+// setupSyncer creates a syncer with the given peers registered and wired back.
+func setupSyncer(peers ...*testPeer) *Syncer {
+	syncer := NewSyncer(rawdb.NewMemoryDatabase(), nil)
+	for _, peer := range peers {
+		syncer.Register(peer)
+		peer.remote = syncer
+	}
+	return syncer
+}
+
+// TestSync tests a basic sync with one peer.
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	cancel := make(chan struct{})
+	sourceAccountTrie, elems := makeAccountTrieNoStorage(100)
+
+	source := newTestPeer("source", t, cancel)
+	source.accountTrie = sourceAccountTrie
+	source.accountValues = elems
+
+	syncer := setupSyncer(source)
+	runSync(t, syncer, sourceAccountTrie.Hash(), cancel)
+	verifyTrie(trie.NewDatabase(syncer.db), sourceAccountTrie.Hash(), t)
+}
+
+// TestMultiSync tests a basic sync with multiple peers.
+func TestMultiSync(t *testing.T) {
+	t.Parallel()
+
+	cancel := make(chan struct{})
+	sourceAccountTrie, elems := makeAccountTrieNoStorage(100)
+
+	var peers []*testPeer
+	for i := 0; i < 2; i++ {
+		peer := newTestPeer(fmt.Sprintf("source%d", i), t, cancel)
+		peer.accountTrie = sourceAccountTrie
+		peer.accountValues = elems
+		peers = append(peers, peer)
+	}
+	syncer := setupSyncer(peers...)
+	runSync(t, syncer, sourceAccountTrie.Hash(), cancel)
+	verifyTrie(trie.NewDatabase(syncer.db), sourceAccountTrie.Hash(), t)
+}
+
+// TestSyncWithStorage tests basic sync using accounts and storage.
+func TestSyncWithStorage(t *testing.T) {
+	t.Parallel()
+
+	cancel := make(chan struct{})
+	sourceAccountTrie, elems, storageTries, storageElems := makeAccountTrieWithStorage(3, 3000)
+
+	source := newTestPeer("source", t, cancel)
+	source.accountTrie = sourceAccountTrie
+	source.accountValues = elems
+	source.storageTries = storageTries
+	source.storageValues = storageElems
+
+	syncer := setupSyncer(source)
+	runSync(t, syncer, sourceAccountTrie.Hash(), cancel)
+	verifyTrie(trie.NewDatabase(syncer.db), sourceAccountTrie.Hash(), t)
+}
+
+// TestSyncResume tests that an interrupted sync persists its progress and that
+// a subsequent sync cycle picks up and completes the state.
+func TestSyncResume(t *testing.T) {
+	t.Parallel()
+
+	cancel := make(chan struct{})
+	sourceAccountTrie, elems := makeAccountTrieNoStorage(100)
+
+	source := newTestPeer("source", t, cancel)
+	source.accountTrie = sourceAccountTrie
+	source.accountValues = elems
+
+	// Abort the first cycle straight away, only the task set gets persisted
+	syncer := setupSyncer(source)
+	close(cancel)
+	if err := syncer.Sync(sourceAccountTrie.Hash(), cancel); err != ErrCancelled {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, ErrCancelled)
+	}
+	if status := rawdb.ReadSnapshotSyncStatus(syncer.db); status == nil {
+		t.Fatalf("sync status not persisted")
+	}
+	// Rerun the sync with a fresh cancel channel and ensure it completes
+	cancel = make(chan struct{})
+	source.cancelCh = cancel
+	runSync(t, syncer, sourceAccountTrie.Hash(), cancel)
+	verifyTrie(trie.NewDatabase(syncer.db), sourceAccountTrie.Hash(), t)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sync/atomic"
+
+	"github.com/odf/go-odf/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// interruptible implements the Stop modfod shared by the native tracers.
+type interruptible struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (it *interruptible) Stop(err error) {
+	it.reason = err
+	atomic.StoreUint32(&it.interrupt, 1)
+}
+
+// interrupted reports whodfer the tracer was asked to stop.
+func (it *interruptible) interrupted() bool {
+	return atomic.LoadUint32(&it.interrupt) > 0
+}
+
+// peekStack returns the nth-from-the-top element of the stack, or zero if the
+// stack is not deep enough, mirroring the JavaScript log.stack.peek.
+func peekStack(stack *vm.Stack, n int) *uint256.Int {
+	if len(stack.Data()) <= n || n < 0 {
+		return new(uint256.Int)
+	}
+	return stack.Back(n)
+}
+
+// peekInt returns the nth-from-the-top element of the stack as an offset or
+// size, saturating values that don't fit into an int64.
+func peekInt(stack *vm.Stack, n int) int64 {
+	val := peekStack(stack, n)
+	if !val.IsUint64() || val.Uint64() > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(val.Uint64())
+}
+
+// sliceMemory returns the requested range of memory, or nil if it is out of
+// bounds, mirroring the JavaScript log.memory.slice.
+func sliceMemory(memory *vm.Memory, begin, end int64) []byte {
+	if end == begin {
+		return []byte{}
+	}
+	if end < begin || begin < 0 || int64(memory.Len()) < end {
+		return nil
+	}
+	return memory.GetCopy(begin, end-begin)
+}
+
+// encodeResult serializes a tracer result the same way the JavaScript runtime
+// does, without escaping HTML characters in error messages.
+func encodeResult(v interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
+	"github.com/odf/go-odf/core/vm"
+	"github.com/odf/go-odf/crypto"
+)
+
+func init() {
+	native["prestateTracer"] = newPrestateTracer
+}
+
+// prestateAccount is the pre-transaction state of a single account. Storage
+// slots are kept in access order so the output matches prestate_tracer.js.
+type prestateAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[common.Hash]common.Hash
+	slots   []common.Hash
+}
+
+// prestateTracer is a native implementation of prestate_tracer.js, outputting
+// sufficient information to create a local execution of the transaction from a
+// custom assembled genesis block.
+type prestateTracer struct {
+	interruptible
+
+	db       vm.StateDB                          // State database of the traced execution
+	prestate map[common.Address]*prestateAccount // Genesis allocations being built
+	accounts []common.Address                    // Accounts in access order
+
+	create bool // Transaction context gathered throughout execution
+	from   common.Address
+	to     common.Address
+	value  *big.Int
+}
+
+// newPrestateTracer creates a native prestate tracer.
+func newPrestateTracer() TxTracer {
+	return new(prestateTracer)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		balance: new(big.Int).Set(t.db.GetBalance(addr)),
+		nonce:   t.db.GetNonce(addr),
+		code:    common.CopyBytes(t.db.GetCode(addr)),
+		storage: make(map[common.Hash]common.Hash),
+	}
+	t.accounts = append(t.accounts, addr)
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	account := t.prestate[addr]
+	if _, ok := account.storage[key]; ok {
+		return
+	}
+	account.storage[key] = t.db.GetState(addr, key)
+	account.slots = append(account.slots, key)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rdata []byte, contract *vm.Contract, depth int, err error) error {
+	if t.interrupted() {
+		return nil
+	}
+	// Add the current account if we just started tracing
+	if t.prestate == nil {
+		t.db = env.StateDB
+		t.prestate = make(map[common.Address]*prestateAccount)
+
+		// Balance will potentially be wrong here, since this will include the value
+		// sent along with the message. We fix that in GetResult.
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.Address(peekStack(stack, 0).Bytes20()))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		offset := peekInt(stack, 1)
+		end := offset + peekInt(stack, 2)
+		code := sliceMemory(memory, offset, end)
+		salt := common.Hash(peekStack(stack, 3).Bytes32())
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.Address(peekStack(stack, 1).Bytes20()))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.Hash(peekStack(stack, 0).Bytes32()))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface, faults don't change the prestate.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements the Tracer interface, the prestate is finalized in GetResult.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the json-encoded prestate of all the accounts touched by
+// the transaction, with the value transfer and nonce bump of the transaction
+// itself reverted.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.interrupted() {
+		return nil, t.reason
+	}
+	if t.prestate == nil {
+		return nil, errors.New("no code executed, prestate unavailable")
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	value := t.value
+	if value == nil {
+		value = new(big.Int)
+	}
+	t.prestate[t.to].balance = new(big.Int).Sub(t.prestate[t.to].balance, value)
+	t.prestate[t.from].balance = new(big.Int).Add(t.prestate[t.from].balance, value)
+
+	// Decrement the caller's nonce, and remove empty create targets
+	t.prestate[t.from].nonce--
+	if t.create {
+		// We can blindly delete the contract prestate, as any existing state would
+		// have caused the transaction to be rejected as invalid in the first place.
+		delete(t.prestate, t.to)
+	}
+	// Assemble the allocations in access order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, addr := range t.accounts {
+		account, ok := t.prestate[addr]
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		buf.WriteString(`"` + hexutil.Encode(addr.Bytes()) + `":{`)
+		buf.WriteString(`"balance":"0x` + account.balance.Text(16) + `",`)
+		buf.WriteString(`"nonce":` + strconv.FormatUint(account.nonce, 10) + `,`)
+		buf.WriteString(`"code":"` + hexutil.Encode(account.code) + `",`)
+		buf.WriteString(`"storage":{`)
+		for i, slot := range account.slots {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`"` + hexutil.Encode(slot.Bytes()) + `":"` + hexutil.Encode(account.storage[slot].Bytes()) + `"`)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
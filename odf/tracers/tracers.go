@@ -14,19 +14,34 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript and native Go transaction tracers.
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
 	"unicode"
 
+	"github.com/odf/go-odf/core/vm"
 	"github.com/odf/go-odf/odf/tracers/internal/tracers"
 )
 
+// TxTracer is the interface implemented by all tracers in this package. Next to
+// the vm.Tracer hooks it allows retrieving the final result of the trace and
+// aborting a running trace.
+type TxTracer interface {
+	vm.Tracer
+	GetResult() (json.RawMessage, error)
+	Stop(err error)
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
+// native contains the constructors of all the built in Go tracers by name. A
+// native tracer takes precedence over a JavaScript one of the same name.
+var native = make(map[string]func() TxTracer)
+
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
 	pieces := strings.Split(str, "_")
@@ -44,6 +59,20 @@ func init() {
 	}
 }
 
+// NewTracer creates a tracer from the given name or JavaScript code. Built in
+// tracers implemented natively in Go are preferred over their JavaScript
+// counterparts, anything else is handed to the JavaScript runtime.
+func NewTracer(code string) (TxTracer, error) {
+	if constructor, ok := native[code]; ok {
+		return constructor(), nil
+	}
+	tracer, err := New(code)
+	if err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
+
 // tracer retrieves a specific JavaScript tracer by name.
 func tracer(name string) (string, bool) {
 	if tracer, ok := all[name]; ok {
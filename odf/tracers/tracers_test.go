@@ -17,6 +17,7 @@
 package tracers
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
@@ -24,6 +25,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -168,39 +170,49 @@ func TestPrestateTracerCreate2(t *testing.T) {
 		Code:    []byte{},
 		Balance: big.NewInt(500000000000000),
 	}
-	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
-
-	// Create the tracer, the EVM environment and run it
-	tracer, err := New("prestateTracer")
+	// Run both the JavaScript and the native tracer, they must agree byte for byte
+	jsTracer, err := New("prestateTracer")
 	if err != nil {
-		t.Fatalf("failed to create call tracer: %v", err)
+		t.Fatalf("failed to create prestate tracer: %v", err)
 	}
-	evm := vm.NewEVM(context, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
-
-	msg, err := tx.AsMessage(signer)
+	nativeTracer, err := NewTracer("prestateTracer")
 	if err != nil {
-		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+		t.Fatalf("failed to create native prestate tracer: %v", err)
 	}
-	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
-	if _, err = st.TransitionDb(); err != nil {
-		t.Fatalf("failed to execute transaction: %v", err)
-	}
-	// Retrieve the trace result and compare against the etalon
-	res, err := tracer.GetResult()
-	if err != nil {
-		t.Fatalf("failed to retrieve trace result: %v", err)
-	}
-	ret := make(map[string]interface{})
-	if err := json.Unmarshal(res, &ret); err != nil {
-		t.Fatalf("failed to unmarshal trace result: %v", err)
+	var results []json.RawMessage
+	for _, tracer := range []TxTracer{jsTracer, nativeTracer} {
+		_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+		evm := vm.NewEVM(context, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+		msg, err := tx.AsMessage(signer, nil)
+		if err != nil {
+			t.Fatalf("failed to prepare transaction for tracing: %v", err)
+		}
+		st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+		if _, err = st.TransitionDb(); err != nil {
+			t.Fatalf("failed to execute transaction: %v", err)
+		}
+		// Retrieve the trace result and compare against the etalon
+		res, err := tracer.GetResult()
+		if err != nil {
+			t.Fatalf("failed to retrieve trace result: %v", err)
+		}
+		ret := make(map[string]interface{})
+		if err := json.Unmarshal(res, &ret); err != nil {
+			t.Fatalf("failed to unmarshal trace result: %v", err)
+		}
+		if _, has := ret["0x60f3f640a8508fc6a86d45df051962668e1e8ac7"]; !has {
+			t.Fatalf("Expected 0x60f3f640a8508fc6a86d45df051962668e1e8ac7 in result")
+		}
+		results = append(results, res)
 	}
-	if _, has := ret["0x60f3f640a8508fc6a86d45df051962668e1e8ac7"]; !has {
-		t.Fatalf("Expected 0x60f3f640a8508fc6a86d45df051962668e1e8ac7 in result")
+	if !bytes.Equal(results[0], results[1]) {
+		t.Fatalf("native prestate mismatch: \nhave %s\nwant %s", results[1], results[0])
 	}
 }
 
 // Iterates over all the input-output datasets in the tracer test harness and
-// runs the JavaScript tracers against them.
+// runs the JavaScript and native tracers against them.
 func TestCallTracer(t *testing.T) {
 	files, err := ioutil.ReadDir("testdata")
 	if err != nil {
@@ -242,44 +254,68 @@ func TestCallTracer(t *testing.T) {
 				GasLimit:    uint64(test.Context.GasLimit),
 				GasPrice:    tx.GasPrice(),
 			}
-			_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
-
-			// Create the tracer, the EVM environment and run it
-			tracer, err := New("callTracer")
+			// Run both the JavaScript and the native tracer on the same prestate
+			jsTracer, err := New("callTracer")
 			if err != nil {
 				t.Fatalf("failed to create call tracer: %v", err)
 			}
-			evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+			jsRes := runCallTracerTest(t, test, tx, signer, context, jsTracer)
 
-			msg, err := tx.AsMessage(signer)
-			if err != nil {
-				t.Fatalf("failed to prepare transaction for tracing: %v", err)
-			}
-			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
-			if _, err = st.TransitionDb(); err != nil {
-				t.Fatalf("failed to execute transaction: %v", err)
-			}
-			// Retrieve the trace result and compare against the etalon
-			res, err := tracer.GetResult()
+			nativeTracer, err := NewTracer("callTracer")
 			if err != nil {
-				t.Fatalf("failed to retrieve trace result: %v", err)
+				t.Fatalf("failed to create native call tracer: %v", err)
 			}
-			ret := new(callTrace)
-			if err := json.Unmarshal(res, ret); err != nil {
-				t.Fatalf("failed to unmarshal trace result: %v", err)
+			if _, ok := nativeTracer.(*callTracer); !ok {
+				t.Fatalf("native call tracer not preferred, have %T", nativeTracer)
 			}
+			nativeRes := runCallTracerTest(t, test, tx, signer, context, nativeTracer)
 
-			if !jsonEqual(ret, test.Result) {
-				// uncomment this for easier debugging
-				//have, _ := json.MarshalIndent(ret, "", " ")
-				//want, _ := json.MarshalIndent(test.Result, "", " ")
-				//t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", string(have), string(want))
-				t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", ret, test.Result)
+			// The two implementations must agree byte for byte, apart from timing
+			if have, want := timeRe.ReplaceAll(nativeRes, nil), timeRe.ReplaceAll(jsRes, nil); !bytes.Equal(have, want) {
+				t.Fatalf("native trace mismatch: \nhave %s\nwant %s", have, want)
 			}
 		})
 	}
 }
 
+// timeRe matches the execution time field of a call trace, which naturally
+// differs between runs.
+var timeRe = regexp.MustCompile(`"time":"[^"]*"`)
+
+// runCallTracerTest executes the transaction of a call tracer test case on its
+// prestate with the given tracer, checks the result against the expected one
+// and returns the raw trace.
+func runCallTracerTest(t *testing.T, test *callTracerTest, tx *types.Transaction, signer types.Signer, context vm.Context, tracer TxTracer) json.RawMessage {
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	// Retrieve the trace result and compare against the etalon
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	ret := new(callTrace)
+	if err := json.Unmarshal(res, ret); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	if !jsonEqual(ret, test.Result) {
+		// uncomment this for easier debugging
+		//have, _ := json.MarshalIndent(ret, "", " ")
+		//want, _ := json.MarshalIndent(test.Result, "", " ")
+		//t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", string(have), string(want))
+		t.Fatalf("trace mismatch: \nhave %+v\nwant %+v", ret, test.Result)
+	}
+	return res
+}
+
 // jsonEqual is similar to reflect.DeepEqual, but does a 'bounce' via json prior to
 // comparison
 func jsonEqual(x, y interface{}) bool {
@@ -28,7 +28,6 @@ import (
 	"github.com/odf/go-odf/common"
 	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/core/types"
-	"github.com/odf/go-odf/rlp"
 	"github.com/odf/go-odf/rpc"
 )
 
@@ -285,6 +284,17 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceipts returns the receipts of all transactions in the given block,
+// identified by number, hash or tag.
+func (ec *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.c.CallContext(ctx, &r, "odf_getBlockReceipts", blockNrOrHash.String())
+	if err == nil && r == nil {
+		return nil, odf.NotFound
+	}
+	return r, err
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -463,7 +473,45 @@ func (ec *Client) PendingTransactionCount(ctx context.Context) (uint, error) {
 	return uint(num), err
 }
 
-// TODO: SubscribePendingTransactions (needs server side)
+// PendingTransaction is a transaction delivered by a full pending transaction
+// subscription, along with its sender.
+type PendingTransaction struct {
+	*types.Transaction
+	From common.Address
+}
+
+// UnmarshalJSON decodes the RPC representation of a pending transaction.
+func (tx *PendingTransaction) UnmarshalJSON(msg []byte) error {
+	var rpcTx rpcTransaction
+	if err := json.Unmarshal(msg, &rpcTx); err != nil {
+		return err
+	}
+	if rpcTx.From == nil {
+		return errors.New("server returned transaction without sender")
+	}
+	tx.Transaction, tx.From = rpcTx.tx, *rpcTx.From
+	return nil
+}
+
+// SubscribeFullPendingTransactions subscribes to the transactions entering the
+// transaction pool of the node which match the given query. Unlike a subscription
+// to transaction hashes, the complete transactions are delivered along with their
+// senders, so they can be processed even if they are evicted from the pool right
+// after arriving.
+func (ec *Client) SubscribeFullPendingTransactions(ctx context.Context, q odf.PendingTransactionQuery, ch chan<- *PendingTransaction) (odf.Subscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "newPendingTransactions", true, toPendingTxArg(q))
+}
+
+func toPendingTxArg(q odf.PendingTransactionQuery) interface{} {
+	arg := map[string]interface{}{
+		"from": q.From,
+		"to":   q.To,
+	}
+	if q.MinGasPrice != nil {
+		arg["minGasPrice"] = (*hexutil.Big)(q.MinGasPrice)
+	}
+	return arg
+}
 
 // Contract Calling
 
@@ -503,6 +551,48 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+// SuggestGasTipCap retrieves the currently suggested gas tip cap after 1559 to
+// allow a timely execution of a transaction.
+func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var hex hexutil.Big
+	if err := ec.c.CallContext(ctx, &hex, "odf_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&hex), nil
+}
+
+type feeHistoryResultMarshaling struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory retrieves the fee market history.
+func (ec *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*odf.FeeHistory, error) {
+	var res feeHistoryResultMarshaling
+	if err := ec.c.CallContext(ctx, &res, "odf_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles); err != nil {
+		return nil, err
+	}
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+	return &odf.FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the backend blockchain. There is no guarantee that this is
 // the true gas limit requirement as other transactions may be added or removed by miners,
@@ -521,7 +611,7 @@ func (ec *Client) EstimateGas(ctx context.Context, msg odf.CallMsg) (uint64, err
 // If the transaction was a contract creation use the TransactionReceipt modfod to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -545,5 +635,14 @@ func toCallArg(msg odf.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
@@ -27,14 +27,16 @@ import (
 
 	"github.com/odf/go-odf"
 	"github.com/odf/go-odf/common"
+	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/consensus/odfash"
 	"github.com/odf/go-odf/core"
 	"github.com/odf/go-odf/core/rawdb"
 	"github.com/odf/go-odf/core/types"
 	"github.com/odf/go-odf/crypto"
-	"github.com/odf/go-odf/odf"
+	odfbackend "github.com/odf/go-odf/odf"
 	"github.com/odf/go-odf/node"
 	"github.com/odf/go-odf/params"
+	"github.com/odf/go-odf/rpc"
 )
 
 // Verify that Client implements the odf interfaces.
@@ -187,15 +189,19 @@ var (
 func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	// Generate test chain.
 	genesis, blocks := generateTestChain()
+	return newTestBackendWithChain(t, genesis, blocks)
+}
+
+func newTestBackendWithChain(t *testing.T, genesis *core.Genesis, blocks []*types.Block) (*node.Node, []*types.Block) {
 	// Create node
 	n, err := node.New(&node.Config{})
 	if err != nil {
 		t.Fatalf("can't create new node: %v", err)
 	}
 	// Create Ethereum Service
-	config := &odf.Config{Genesis: genesis}
+	config := &odfbackend.Config{Genesis: genesis}
 	config.Ethash.PowMode = odfash.ModeFake
-	odfservice, err := odf.New(n, config)
+	odfservice, err := odfbackend.New(n, config)
 	if err != nil {
 		t.Fatalf("can't create new odf service: %v", err)
 	}
@@ -368,3 +374,168 @@ func TestBlockNumber(t *testing.T) {
 		t.Fatalf("BlockNumber returned wrong number: %d", blockNumber)
 	}
 }
+
+func TestBlockReceipts(t *testing.T) {
+	// Generate a London chain with a legacy and a dynamic fee transaction in
+	// the first block, followed by an empty block
+	config := *params.AllEthashProtocolChanges
+	config.LondonBlock = big.NewInt(0)
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		funds   = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+		genesis = &core.Genesis{
+			Config:    &config,
+			Alloc:     core.GenesisAlloc{testAddr: {Balance: funds}},
+			ExtraData: []byte("test genesis"),
+			Timestamp: 9000,
+		}
+		signer = types.LatestSigner(&config)
+		gblock = genesis.ToBlock(db)
+	)
+	blocks, _ := core.GenerateChain(&config, gblock, odfash.NewFaker(), db, 2, func(i int, g *core.BlockGen) {
+		g.OffsetTime(5)
+		if i != 0 {
+			return
+		}
+		legacy := types.MustSignNewTx(testKey, signer, &types.LegacyTx{
+			Nonce:    0,
+			GasPrice: big.NewInt(2 * params.GWei),
+			Gas:      params.TxGas,
+			To:       &common.Address{0xaa},
+			Value:    big.NewInt(1),
+		})
+		g.AddTx(legacy)
+		dynamic := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     1,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(5 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &common.Address{0xbb},
+			Value:     big.NewInt(1),
+		})
+		g.AddTx(dynamic)
+	})
+	blocks = append([]*types.Block{gblock}, blocks...)
+
+	backend, _ := newTestBackendWithChain(t, genesis, blocks)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	ec := NewClient(client)
+
+	// Retrieve the receipts of the populated block by number and by hash
+	for _, id := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(1),
+		rpc.BlockNumberOrHashWithHash(blocks[1].Hash(), true),
+	} {
+		receipts, err := ec.BlockReceipts(context.Background(), id)
+		if err != nil {
+			t.Fatalf("%v: failed to retrieve receipts: %v", id, err)
+		}
+		txs := blocks[1].Transactions()
+		if len(receipts) != len(txs) {
+			t.Fatalf("%v: receipt count mismatch: have %d, want %d", id, len(receipts), len(txs))
+		}
+		for i, receipt := range receipts {
+			if receipt.TxHash != txs[i].Hash() {
+				t.Errorf("%v: receipt %d: tx hash mismatch: have %x, want %x", id, i, receipt.TxHash, txs[i].Hash())
+			}
+			if receipt.Type != txs[i].Type() {
+				t.Errorf("%v: receipt %d: type mismatch: have %d, want %d", id, i, receipt.Type, txs[i].Type())
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				t.Errorf("%v: receipt %d: status mismatch: have %d, want %d", id, i, receipt.Status, types.ReceiptStatusSuccessful)
+			}
+			if receipt.BlockHash != blocks[1].Hash() {
+				t.Errorf("%v: receipt %d: block hash mismatch: have %x, want %x", id, i, receipt.BlockHash, blocks[1].Hash())
+			}
+		}
+	}
+	// The empty block must yield an empty list, not a missing block
+	receipts, err := ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(2))
+	if err != nil {
+		t.Fatalf("failed to retrieve empty block receipts: %v", err)
+	}
+	if len(receipts) != 0 {
+		t.Errorf("empty block receipt count mismatch: have %d, want 0", len(receipts))
+	}
+	// Unknown blocks are reported as not found, the pending block is rejected
+	if _, err := ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(1000)); err != odf.NotFound {
+		t.Errorf("unknown block error mismatch: have %v, want %v", err, odf.NotFound)
+	}
+	if _, err := ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)); err == nil {
+		t.Errorf("pending block receipts returned")
+	}
+	// Check the effective gas prices reported by the raw RPC method
+	var raw []map[string]interface{}
+	if err := client.Call(&raw, "odf_getBlockReceipts", "0x1"); err != nil {
+		t.Fatalf("failed to retrieve raw receipts: %v", err)
+	}
+	baseFee := blocks[1].BaseFee()
+	for i, want := range []*big.Int{
+		big.NewInt(2 * params.GWei),
+		new(big.Int).Add(baseFee, big.NewInt(params.GWei)),
+	} {
+		if have := raw[i]["effectiveGasPrice"]; have != hexutil.EncodeBig(want) {
+			t.Errorf("receipt %d: effective gas price mismatch: have %v, want %v", i, have, hexutil.EncodeBig(want))
+		}
+	}
+}
+
+func TestSubscribeFullPendingTransactions(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	ec := NewClient(client)
+
+	// Subscribe to the well priced transactions of the test account
+	ch := make(chan *PendingTransaction, 2)
+	query := odf.PendingTransactionQuery{From: []common.Address{testAddr}, MinGasPrice: big.NewInt(1000)}
+	sub, err := ec.SubscribeFullPendingTransactions(context.Background(), query, ch)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	time.Sleep(time.Second)
+
+	// Send a cheap transaction, followed by a well priced one
+	signer := types.LatestSigner(params.AllEthashProtocolChanges)
+	var txs []*types.Transaction
+	for nonce, price := range []int64{1, 100000} {
+		tx, err := types.SignTx(types.NewTransaction(uint64(nonce), common.Address{0xaa}, big.NewInt(1), params.TxGas, big.NewInt(price), nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		if err := ec.SendTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("failed to send transaction: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	select {
+	case tx := <-ch:
+		if tx.Hash() != txs[1].Hash() {
+			t.Fatalf("transaction mismatch: have %x, want %x", tx.Hash(), txs[1].Hash())
+		}
+		if tx.From != testAddr {
+			t.Fatalf("sender mismatch: have %x, want %x", tx.From, testAddr)
+		}
+		if *tx.To() != *txs[1].To() || tx.Value().Cmp(txs[1].Value()) != 0 {
+			t.Fatalf("transaction fields mismatch")
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for pending transaction")
+	}
+	select {
+	case tx := <-ch:
+		t.Fatalf("unexpected transaction %x", tx.Hash())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package odfdb
+
+// CopyProgress is invoked by CopyRange after every flush with the total number
+// of entries copied so far and the start position from which an interrupted
+// copy can be resumed. Returning an error aborts the copy.
+type CopyProgress func(count uint64, next []byte) error
+
+// CopyRange streams every key-value pair with the given prefix from the source
+// store into the destination, starting at the given start position (or after
+// it, if it does not exist). Like with NewIterator, the start position does not
+// include the prefix.
+//
+// If the destination is a Batcher, writes are accumulated into a batch which is
+// flushed whenever it exceeds IdealBatchSize, otherwise they are forwarded one
+// by one. Either way the memory usage is bounded regardless of the range size.
+//
+// The optional progress callback is invoked after every flush and once more at
+// the end of the range, with a nil resume position.
+func CopyRange(dst KeyValueWriter, src Iteratee, prefix []byte, start []byte, progress CopyProgress) (uint64, error) {
+	it := src.NewIterator(prefix, start)
+	defer it.Release()
+
+	var (
+		writer = dst
+		batch  Batch
+		count  uint64
+		size   int
+	)
+	if batcher, ok := dst.(Batcher); ok {
+		batch = batcher.NewBatch()
+		writer = batch
+	}
+	for it.Next() {
+		key := it.Key()
+		if err := writer.Put(key, it.Value()); err != nil {
+			return count, err
+		}
+		count++
+		size += len(key) + len(it.Value())
+
+		if size < IdealBatchSize {
+			continue
+		}
+		if batch != nil {
+			if err := batch.Write(); err != nil {
+				return count, err
+			}
+			batch.Reset()
+		}
+		size = 0
+
+		if progress != nil {
+			// Resume right after the last key written, the zero byte suffix makes
+			// it the smallest key larger than the current one.
+			next := make([]byte, len(key)-len(prefix)+1)
+			copy(next, key[len(prefix):])
+
+			if err := progress(count, next); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+	if batch != nil {
+		if err := batch.Write(); err != nil {
+			return count, err
+		}
+	}
+	if progress != nil {
+		if err := progress(count, nil); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -64,6 +64,7 @@ type KeyValueStore interface {
 	Iteratee
 	Stater
 	Compacter
+	Snapshotter
 	io.Closer
 }
 
@@ -81,6 +82,10 @@ type AncientReader interface {
 
 	// AncientSize returns the ancient size of the specified category.
 	AncientSize(kind string) (uint64, error)
+
+	// Tail returns the number of the first block whose body and receipts are
+	// still available in the ancient store, all older ones were pruned.
+	Tail() (uint64, error)
 }
 
 // AncientWriter contains the modfods required to write to immutable ancient data.
@@ -92,6 +97,10 @@ type AncientWriter interface {
 	// TruncateAncients discards all but the first n ancient data from the ancient store.
 	TruncateAncients(n uint64) error
 
+	// TruncateTail discards the block bodies and receipts below the given number
+	// from the ancient store, retaining the headers.
+	TruncateTail(tail uint64) error
+
 	// Sync flushes all in-memory ancient store data to disk.
 	Sync() error
 }
@@ -127,5 +136,6 @@ type Database interface {
 	Iteratee
 	Stater
 	Compacter
+	Snapshotter
 	io.Closer
 }
@@ -18,6 +18,8 @@ package dbtest
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -313,6 +315,123 @@ func TestDatabaseSuite(t *testing.T, New func() odfdb.KeyValueStore) {
 		}
 	})
 
+	t.Run("Snapshot", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		initial := map[string]string{
+			"k1": "v1", "k2": "v2", "k3": "", "k4": "",
+		}
+		for k, v := range initial {
+			if err := db.Put([]byte(k), []byte(v)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		snapshot, err := db.NewSnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Mutate the database both directly and through a batch
+		if err := db.Put([]byte("k1"), []byte("v1-new")); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete([]byte("k2")); err != nil {
+			t.Fatal(err)
+		}
+		b := db.NewBatch()
+		b.Put([]byte("k5"), []byte("v5"))
+		b.Delete([]byte("k3"))
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+		// The snapshot must still reflect the initial content
+		for k, v := range initial {
+			got, err := snapshot.Get([]byte(k))
+			if err != nil {
+				t.Fatalf("snapshot get %q: %v", k, err)
+			}
+			if !bytes.Equal(got, []byte(v)) {
+				t.Fatalf("snapshot get %q: have %q, want %q", k, got, v)
+			}
+			ok, err := snapshot.Has([]byte(k))
+			if err != nil || !ok {
+				t.Fatalf("snapshot has %q: have %t (%v), want true", k, ok, err)
+			}
+		}
+		if ok, err := snapshot.Has([]byte("k5")); err != nil || ok {
+			t.Fatalf("snapshot has k5: have %t (%v), want false", ok, err)
+		}
+		if _, err := snapshot.Get([]byte("k5")); err == nil {
+			t.Fatal("snapshot get k5: expected error")
+		}
+		// The database itself must reflect the mutations
+		if got, err := db.Get([]byte("k1")); err != nil || !bytes.Equal(got, []byte("v1-new")) {
+			t.Fatalf("db get k1: have %q (%v), want %q", got, err, "v1-new")
+		}
+		if ok, _ := db.Has([]byte("k2")); ok {
+			t.Fatal("db has k2 after deletion")
+		}
+		if ok, _ := db.Has([]byte("k5")); !ok {
+			t.Fatal("db misses k5 after batch write")
+		}
+		// A second snapshot sees the new state, releasing is idempotent
+		snapshot2, err := db.NewSnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := snapshot2.Has([]byte("k5")); err != nil || !ok {
+			t.Fatalf("second snapshot has k5: have %t (%v), want true", ok, err)
+		}
+		snapshot.Release()
+		snapshot.Release()
+		snapshot2.Release()
+	})
+
+	t.Run("CopyRange", func(t *testing.T) {
+		src, dst := New(), New()
+		defer src.Close()
+		defer dst.Close()
+
+		// Fill the source with enough data to require multiple flushes
+		value := bytes.Repeat([]byte{0x01}, 1024)
+		for i := 0; i < 300; i++ {
+			src.Put([]byte(fmt.Sprintf("a%04d", i)), value)
+			src.Put([]byte(fmt.Sprintf("b%04d", i)), value)
+		}
+		// Interrupt the copy after the first flush
+		errAbort := errors.New("abort")
+
+		var marker []byte
+		count, err := odfdb.CopyRange(dst, src, []byte("a"), nil, func(count uint64, next []byte) error {
+			marker = next
+			return errAbort
+		})
+		if err != errAbort {
+			t.Fatalf("copy error mismatch: have %v, want %v", err, errAbort)
+		}
+		if count == 0 || count >= 300 || marker == nil {
+			t.Fatalf("copy not interrupted midway: copied %d, marker %x", count, marker)
+		}
+		// Resume from the marker and ensure everything is copied exactly once
+		var final uint64
+		resumed, err := odfdb.CopyRange(dst, src, []byte("a"), marker, func(count uint64, next []byte) error {
+			if next == nil {
+				final = count
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count+resumed != 300 || final != resumed {
+			t.Fatalf("copied item count mismatch: have %d+%d (final %d), want 300", count, resumed, final)
+		}
+		it := dst.NewIterator(nil, nil)
+		keys := iterateKeys(it)
+		if len(keys) != 300 || keys[0] != "a0000" || keys[299] != "a0299" {
+			t.Fatalf("destination content mismatch: %d keys", len(keys))
+		}
+	})
 }
 
 func iterateKeys(it odfdb.Iterator) []string {
@@ -35,6 +35,10 @@ var (
 	// errMemorydbNotFound is returned if a key is requested that is not found in
 	// the provided memory database.
 	errMemorydbNotFound = errors.New("not found")
+
+	// errSnapshotReleased is returned if callers want to retrieve data from a
+	// released snapshot.
+	errSnapshotReleased = errors.New("snapshot released")
 )
 
 // Database is an ephemeral key-value store. Apart from basic data storage
@@ -43,6 +47,11 @@ var (
 type Database struct {
 	db   map[string][]byte
 	lock sync.RWMutex
+
+	// The key-value map is shared with the live snapshots taken since the last
+	// mutation and is copied on the next write instead of being updated in place.
+	snaps int    // Number of live snapshots sharing the current map
+	gen   uint64 // Generation of the current map, bumped on every copy
 }
 
 // New returns a wrapped map with all the required database interface modfods
@@ -105,6 +114,7 @@ func (db *Database) Put(key []byte, value []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
+	db.detach()
 	db.db[string(key)] = common.CopyBytes(value)
 	return nil
 }
@@ -117,6 +127,7 @@ func (db *Database) Delete(key []byte) error {
 	if db.db == nil {
 		return errMemorydbClosed
 	}
+	db.detach()
 	delete(db.db, string(key))
 	return nil
 }
@@ -163,6 +174,54 @@ func (db *Database) NewIterator(prefix []byte, start []byte) odfdb.Iterator {
 	}
 }
 
+// NewSnapshot creates a database snapshot based on the current state.
+// The created snapshot will not be affected by all following mutations
+// happened on the database.
+//
+// The snapshot shares the key-value map with the database, which is only
+// copied once the database is written to while the snapshot is still live.
+func (db *Database) NewSnapshot() (odfdb.Snapshot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return nil, errMemorydbClosed
+	}
+	db.snaps++
+	return &snapshot{
+		db:    db.db,
+		owner: db,
+		gen:   db.gen,
+	}, nil
+}
+
+// detach copies the key-value map if it's shared with any live snapshot, so
+// that subsequent writes don't leak into them. The caller must hold the write
+// lock.
+func (db *Database) detach() {
+	if db.snaps == 0 {
+		return
+	}
+	cpy := make(map[string][]byte, len(db.db))
+	for key, value := range db.db {
+		cpy[key] = value // values are never modified in place
+	}
+	db.db = cpy
+	db.snaps = 0
+	db.gen++
+}
+
+// release is called by a snapshot when it's no longer used, to avoid copying
+// the key-value map on write if no other snapshot shares it.
+func (db *Database) release(gen uint64) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.gen == gen && db.snaps > 0 {
+		db.snaps--
+	}
+}
+
 // Stat returns a particular internal stat of the database.
 func (db *Database) Stat(property string) (string, error) {
 	return "", errors.New("unknown property")
@@ -225,6 +284,10 @@ func (b *batch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
+	if b.db.db == nil {
+		return errMemorydbClosed
+	}
+	b.db.detach()
 	for _, keyvalue := range b.writes {
 		if keyvalue.delete {
 			delete(b.db.db, string(keyvalue.key))
@@ -313,3 +376,55 @@ func (it *iterator) Value() []byte {
 func (it *iterator) Release() {
 	it.keys, it.values = nil, nil
 }
+
+// snapshot is a point-in-time view of a memory database. It shares the key-value
+// map with its host database until the latter is modified.
+type snapshot struct {
+	db    map[string][]byte
+	owner *Database
+	gen   uint64
+	lock  sync.RWMutex
+}
+
+// Has retrieves if a key is present in the snapshot backing by a key-value
+// data store.
+func (snap *snapshot) Has(key []byte) (bool, error) {
+	snap.lock.RLock()
+	defer snap.lock.RUnlock()
+
+	if snap.db == nil {
+		return false, errSnapshotReleased
+	}
+	// The shared map is never written to while the snapshot is live, the host
+	// database copies it away first.
+	_, ok := snap.db[string(key)]
+	return ok, nil
+}
+
+// Get retrieves the given key if it's present in the snapshot backing by
+// key-value data store.
+func (snap *snapshot) Get(key []byte) ([]byte, error) {
+	snap.lock.RLock()
+	defer snap.lock.RUnlock()
+
+	if snap.db == nil {
+		return nil, errSnapshotReleased
+	}
+	if entry, ok := snap.db[string(key)]; ok {
+		return common.CopyBytes(entry), nil
+	}
+	return nil, errMemorydbNotFound
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (snap *snapshot) Release() {
+	snap.lock.Lock()
+	defer snap.lock.Unlock()
+
+	if snap.db == nil {
+		return
+	}
+	snap.db = nil
+	snap.owner.release(snap.gen)
+}
@@ -102,13 +102,30 @@ Signed integers, floating point numbers, maps, channels and functions cannot be
 
 Struct Tags
 
-Package rlp honours certain struct tags: "-", "tail", "nil", "nilList" and "nilString".
+Package rlp honours certain struct tags: "-", "tail", "nil", "nilList", "nilString" and
+"optional".
 
 The "-" tag ignores fields.
 
 The "tail" tag, which may only be used on the last exported struct field, allows slurping
 up any excess list elements into a slice. See examples for more details.
 
+The "optional" tag says that the field may be omitted if it is zero-valued. If this tag is
+used on a struct field, all subsequent public fields must also be declared optional.
+
+When encoding a struct with optional fields, the output RLP list contains all values up to
+the last non-zero optional field.
+
+When decoding into a struct, optional fields may be omitted from the end of the input
+list. For the example below, this means input lists of one, two, or three elements are
+accepted.
+
+   type StructWithOptionalFields struct {
+        Required  uint64
+        Optional1 uint64 `rlp:"optional"`
+        Optional2 uint64 `rlp:"optional"`
+   }
+
 The "nil" tag applies to pointer-typed fields and changes the decoding rules for the field
 such that input values of size zero decode as a nil pointer. This tag can be useful when
 decoding recursive types.
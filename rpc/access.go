@@ -0,0 +1,86 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessControl restricts the modfods which may be called on a server. Every entry
+// is either a namespace such as "debug", covering all modfods of that namespace, or
+// a single modfod such as "debug_traceTransaction".
+//
+// If Allow is empty, all modfods are allowed unless denied. Otherwise a modfod must
+// match an entry of Allow and none of Deny.
+type AccessControl struct {
+	Allow []string `toml:",omitempty"`
+	Deny  []string `toml:",omitempty"`
+}
+
+// accessList is the validated form of an AccessControl.
+type accessList struct {
+	allow []string
+	deny  []string
+}
+
+// newAccessList validates the entries of ac, returning nil if it doesn't restrict
+// any modfods.
+func newAccessList(ac AccessControl) (*accessList, error) {
+	if len(ac.Allow) == 0 && len(ac.Deny) == 0 {
+		return nil, nil
+	}
+	for _, entry := range append(append([]string{}, ac.Allow...), ac.Deny...) {
+		if err := validateAccessEntry(entry); err != nil {
+			return nil, err
+		}
+	}
+	return &accessList{allow: ac.Allow, deny: ac.Deny}, nil
+}
+
+// validateAccessEntry checks that entry is a well formed namespace or modfod name.
+func validateAccessEntry(entry string) error {
+	elem := strings.Split(entry, serviceModfodSeparator)
+	if len(elem) > 2 {
+		return fmt.Errorf("invalid access control entry %q", entry)
+	}
+	for _, e := range elem {
+		if e == "" || strings.ContainsAny(e, " \t\n") {
+			return fmt.Errorf("invalid access control entry %q", entry)
+		}
+	}
+	return nil
+}
+
+// permits reports whether the given modfod may be called.
+func (l *accessList) permits(modfod string) bool {
+	if len(l.allow) > 0 && !matchAccessEntries(l.allow, modfod) {
+		return false
+	}
+	return !matchAccessEntries(l.deny, modfod)
+}
+
+// matchAccessEntries reports whether modfod is covered by any of the entries.
+func matchAccessEntries(entries []string, modfod string) bool {
+	namespace := strings.SplitN(modfod, serviceModfodSeparator, 2)[0]
+	for _, entry := range entries {
+		if entry == modfod || entry == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JWTFreshness is the maximum allowed difference between the issuance time of an
+// authentication token and the local clock of the server.
+const JWTFreshness = 60 * time.Second
+
+var (
+	errMissingToken   = errors.New("missing token")
+	errMalformedJWT   = errors.New("malformed token")
+	errUnsupportedJWT = errors.New("unsupported token algorithm")
+	errInvalidJWTSig  = errors.New("invalid token signature")
+	errStaleToken     = errors.New("stale token")
+	errFutureToken    = errors.New("token issued in the future")
+
+	// jwtHeader is the encoded header of all tokens created by NewJWTAuth.
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// HTTPAuth is a function that adds authentication headers to an outgoing HTTP
+// request or WebSocket handshake.
+type HTTPAuth func(h http.Header) error
+
+// jwtClaims are the token claims understood by the server.
+type jwtClaims struct {
+	IssuedAt *int64 `json:"iat"`
+}
+
+// NewJWTAuth creates an HTTPAuth which authenticates requests with HS256 signed
+// JSON web tokens. A fresh token is issued for every request.
+func NewJWTAuth(secret [32]byte) HTTPAuth {
+	return func(h http.Header) error {
+		token, err := newJWT(secret[:], time.Now())
+		if err != nil {
+			return err
+		}
+		h.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// VerifyJWTAuth checks that the given request headers carry a bearer token signed
+// with secret and issued within JWTFreshness of the local clock.
+func VerifyJWTAuth(secret [32]byte, h http.Header) error {
+	auth := h.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return errMissingToken
+	}
+	return verifyJWT(secret[:], strings.TrimPrefix(auth, "Bearer "), time.Now())
+}
+
+// newJWT creates a token with an iat claim of now, signed with secret.
+func newJWT(secret []byte, now time.Time) (string, error) {
+	iat := now.Unix()
+	claims, err := json.Marshal(&jwtClaims{IssuedAt: &iat})
+	if err != nil {
+		return "", err
+	}
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, signed)), nil
+}
+
+// verifyJWT checks the signature of token and that its iat claim is within
+// JWTFreshness of now.
+func verifyJWT(secret []byte, token string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errMalformedJWT
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "HS256" {
+		return fmt.Errorf("%w: %q", errUnsupportedJWT, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errMalformedJWT
+	}
+	if !hmac.Equal(sig, jwtSignature(secret, parts[0]+"."+parts[1])) {
+		return errInvalidJWTSig
+	}
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.IssuedAt == nil {
+		return fmt.Errorf("%w: missing iat claim", errMalformedJWT)
+	}
+	iat := time.Unix(*claims.IssuedAt, 0)
+	switch {
+	case now.Sub(iat) > JWTFreshness:
+		return errStaleToken
+	case iat.Sub(now) > JWTFreshness:
+		return errFutureToken
+	}
+	return nil
+}
+
+// decodeJWTPart decodes a base64 encoded JSON segment of a token.
+func decodeJWTPart(part string, v interface{}) error {
+	blob, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errMalformedJWT
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return errMalformedJWT
+	}
+	return nil
+}
+
+// jwtSignature computes the HS256 signature of the signed portion of a token.
+func jwtSignature(secret []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
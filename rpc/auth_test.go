@@ -0,0 +1,68 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTVerification(t *testing.T) {
+	var (
+		secret = []byte("0123456789abcdef0123456789abcdef")
+		now    = time.Unix(1600000000, 0)
+	)
+	token := func(issued time.Time) string {
+		tok, err := newJWT(secret, issued)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	valid := token(now)
+	parts := strings.Split(valid, ".")
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{valid, nil},
+		{token(now.Add(-JWTFreshness + time.Second)), nil},
+		{token(now.Add(JWTFreshness - time.Second)), nil},
+		{token(now.Add(-JWTFreshness - time.Second)), errStaleToken},
+		{token(now.Add(JWTFreshness + time.Second)), errFutureToken},
+		{parts[0] + "." + parts[1], errMalformedJWT},
+		{parts[0] + "." + parts[1] + ".!", errMalformedJWT},
+		{parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), errInvalidJWTSig},
+		{unsigned, errUnsupportedJWT},
+	}
+	for i, test := range tests {
+		err := verifyJWT(secret, test.token, now)
+		if test.err == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if test.err != nil && (err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+	if err := verifyJWT([]byte("other secret"), valid, now); err != errInvalidJWTSig {
+		t.Errorf("wrong secret: error mismatch: have %v, want %v", err, errInvalidJWTSig)
+	}
+}
@@ -310,6 +310,51 @@ func TestClientSubscribe(t *testing.T) {
 	}
 }
 
+// This test checks that subscriptions terminated by the server deliver the queued
+// notifications followed by the error, both on the client and the server side.
+func TestClientSubscribeServerClose(t *testing.T) {
+	for _, count := range []int{0, 10} {
+		server := newTestServer()
+		service := &notificationTestService{unsubscribed: make(chan string, 1)}
+		if err := server.RegisterName("nftest2", service); err != nil {
+			t.Fatal(err)
+		}
+		client := DialInProc(server)
+
+		nc := make(chan int)
+		sub, err := client.Subscribe(context.Background(), "nftest2", nc, "failingSubscription", count, 0)
+		if err != nil {
+			t.Fatal("can't subscribe:", err)
+		}
+		for i := 0; i < count; i++ {
+			if val := <-nc; val != i {
+				t.Fatalf("value mismatch: got %d, want %d", val, i)
+			}
+		}
+		select {
+		case v := <-nc:
+			t.Fatal("received value after server close:", v)
+		case err := <-sub.Err():
+			rpcErr, ok := err.(Error)
+			if !ok || rpcErr.Error() != "testError" || rpcErr.ErrorCode() != 444 {
+				t.Fatalf("wrong subscription error: %v", err)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("subscription not closed within 1s after server close")
+		}
+		select {
+		case err := <-service.unsubscribed:
+			if err != "testError" {
+				t.Fatalf("wrong server side error: %v", err)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("server side error not delivered within 1s")
+		}
+		client.Close()
+		server.Stop()
+	}
+}
+
 // In this test, the connection drops while Subscribe is waiting for a response.
 func TestClientSubscribeClose(t *testing.T) {
 	server := newTestServer()
@@ -24,8 +24,17 @@ import (
 
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+	return StartIPCEndpointWithAccess(ipcEndpoint, apis, AccessControl{})
+}
+
+// StartIPCEndpointWithAccess starts an IPC endpoint which only serves the modfods
+// permitted by access.
+func StartIPCEndpointWithAccess(ipcEndpoint string, apis []API, access AccessControl) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	handler := NewServer()
+	if err := handler.SetAccessControl(access); err != nil {
+		return nil, nil, err
+	}
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return nil, nil, err
@@ -20,11 +20,13 @@ import "fmt"
 
 var (
 	_ Error = new(modfodNotFoundError)
+	_ Error = new(modfodNotAllowedError)
 	_ Error = new(subscriptionNotFoundError)
 	_ Error = new(parseError)
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(limitExceededError)
 )
 
 const defaultErrorCode = -32000
@@ -37,6 +39,14 @@ func (e *modfodNotFoundError) Error() string {
 	return fmt.Sprintf("the modfod %s does not exist/is not available", e.modfod)
 }
 
+type modfodNotAllowedError struct{ modfod string }
+
+func (e *modfodNotAllowedError) ErrorCode() int { return -32601 }
+
+func (e *modfodNotAllowedError) Error() string {
+	return fmt.Sprintf("the modfod %s is not allowed on this endpoint", e.modfod)
+}
+
 type subscriptionNotFoundError struct{ namespace, subscription string }
 
 func (e *subscriptionNotFoundError) ErrorCode() int { return -32601 }
@@ -72,3 +82,10 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// request exceeds a limit imposed by the server
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
@@ -61,17 +61,33 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	cfg            handlerConfig // server-side settings, empty for clients
+	remoteHost     string        // remote host for rate limiting
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
 }
 
+// maxLoggedParams is the number of bytes of call parameters included in slow-call logs.
+const maxLoggedParams = 256
+
+// handlerConfig holds the server-side settings of a handler: the resource limits,
+// access list and slow-call logging applied to the requests of a single client.
+// The zero value imposes no restrictions.
+type handlerConfig struct {
+	batchItems    int           // maximum number of requests in a batch
+	responseBytes int           // maximum number of result bytes in a response
+	limiter       *rateLimiter  // per-client, per-modfod call rate limiter
+	access        *accessList   // modfods the client may call
+	slowCall      time.Duration // calls taking longer than this are logged (0 = off)
+}
+
 type callProc struct {
 	ctx       context.Context
 	notifiers []*Notifier
 }
 
-func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
+func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry, cfg handlerConfig) *handler {
 	rootCtx, cancelRoot := context.WithCancel(connCtx)
 	h := &handler{
 		reg:            reg,
@@ -84,9 +100,11 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		allowSubscribe: true,
 		serverSubs:     make(map[ID]*Subscription),
 		log:            log.Root(),
+		cfg:            cfg,
 	}
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
+		h.remoteHost = limiterHost(conn.remoteAddr())
 	}
 	h.unsubscribeCb = newCallback(reflect.Value{}, reflect.ValueOf(h.unsubscribe))
 	return h
@@ -101,6 +119,13 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	// Reject oversized batches without processing any of their messages:
+	if h.cfg.batchItems > 0 && len(msgs) > h.cfg.batchItems {
+		h.startCallProc(func(cp *callProc) {
+			h.conn.writeJSON(cp.ctx, errorMessage(&invalidRequestError{errMsgBatchTooLarge}))
+		})
+		return
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -114,11 +139,27 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	}
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
-		answers := make([]*jsonrpcMessage, 0, len(msgs))
+		var (
+			answers = make([]*jsonrpcMessage, 0, len(msgs))
+			size    int
+		)
 		for _, msg := range calls {
-			if answer := h.handleCallMsg(cp, msg); answer != nil {
-				answers = append(answers, answer)
+			// Once the response is too large, don't bother executing further calls.
+			if h.responseTooLarge(size) && msg.isCall() {
+				answers = append(answers, msg.errorResponse(&limitExceededError{errMsgResponseTooLarge}))
+				continue
+			}
+			answer := h.handleCallMsg(cp, msg)
+			if answer == nil {
+				continue
 			}
+			if answer.Result != nil {
+				size += len(answer.Result)
+				if h.responseTooLarge(size) {
+					answer = msg.errorResponse(&limitExceededError{errMsgResponseTooLarge})
+				}
+			}
+			answers = append(answers, answer)
 		}
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
@@ -137,6 +178,9 @@ func (h *handler) handleMsg(msg *jsonrpcMessage) {
 	}
 	h.startCallProc(func(cp *callProc) {
 		answer := h.handleCallMsg(cp, msg)
+		if answer != nil && h.responseTooLarge(len(answer.Result)) {
+			answer = msg.errorResponse(&limitExceededError{errMsgResponseTooLarge})
+		}
 		h.addSubscriptions(cp.notifiers)
 		if answer != nil {
 			h.conn.writeJSON(cp.ctx, answer)
@@ -147,6 +191,12 @@ func (h *handler) handleMsg(msg *jsonrpcMessage) {
 	})
 }
 
+// responseTooLarge reports whether a response carrying size bytes of results
+// exceeds the configured limit.
+func (h *handler) responseTooLarge(size int) bool {
+	return h.cfg.responseBytes > 0 && size > h.cfg.responseBytes
+}
+
 // close cancels all requests except for inflightReq and waits for
 // call goroutines to shut down.
 func (h *handler) close(err error, inflightReq *requestOp) {
@@ -216,6 +266,23 @@ func (h *handler) cancelServerSubscriptions(err error) {
 	}
 }
 
+// closeServerSubscription removes a subscription terminated by the server and
+// delivers err on its error channel, unless the client unsubscribed already.
+func (h *handler) closeServerSubscription(n *Notifier, err error) {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	sub := n.sub
+	if n.registered {
+		if h.serverSubs[sub.ID] != sub {
+			return
+		}
+		delete(h.serverSubs, sub.ID)
+	}
+	sub.err <- err
+	close(sub.err)
+}
+
 // startCallProc runs fn in a new goroutine and starts tracking it in the h.calls wait group.
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
@@ -254,9 +321,16 @@ func (h *handler) handleSubscriptionResult(msg *jsonrpcMessage) {
 		h.log.Debug("Dropping invalid subscription message")
 		return
 	}
-	if h.clientSubs[result.ID] != nil {
-		h.clientSubs[result.ID].deliver(result.Result)
+	sub := h.clientSubs[result.ID]
+	if sub == nil {
+		return
+	}
+	if result.Error != nil {
+		delete(h.clientSubs, result.ID)
+		sub.close(result.Error)
+		return
 	}
+	sub.deliver(result.Result)
 }
 
 // handleResponse processes modfod call responses.
@@ -317,6 +391,12 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes modfod calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.cfg.access != nil && !h.cfg.access.permits(msg.Modfod) {
+		return msg.errorResponse(&modfodNotAllowedError{modfod: msg.Modfod})
+	}
+	if h.cfg.limiter != nil && !msg.isUnsubscribe() && !h.cfg.limiter.allow(h.remoteHost, h.limiterModfod(msg)) {
+		return msg.errorResponse(&limitExceededError{errMsgRateLimited})
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -335,6 +415,7 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	}
 	start := time.Now()
 	answer := h.runModfod(cp.ctx, msg, callb, args)
+	elapsed := time.Since(start)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -345,12 +426,43 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		} else {
 			successfulRequestGauge.Inc(1)
 		}
-		rpcServingTimer.UpdateSince(start)
-		newRPCServingTimer(msg.Modfod, answer.Error == nil).UpdateSince(start)
+		rpcServingTimer.Update(elapsed)
+		newRPCServingTimer(msg.Modfod, answer.Error == nil).Update(elapsed)
+		updateRPCModfodMetrics(msg.Modfod, elapsed, len(msg.Params), len(answer.Result), answer.Error != nil)
+	}
+	if h.cfg.slowCall > 0 && elapsed > h.cfg.slowCall {
+		rpcSlowCallMeter.Mark(1)
+		h.log.Warn("Slow RPC call", "modfod", msg.Modfod, "reqid", idForLog{msg.ID}, "t", elapsed,
+			"reqsize", len(msg.Params), "respsize", len(answer.Result), "params", truncateParams(msg.Params))
 	}
 	return answer
 }
 
+// truncateParams formats call parameters for logging, cutting them off after
+// maxLoggedParams bytes.
+func truncateParams(params json.RawMessage) string {
+	if len(params) <= maxLoggedParams {
+		return string(params)
+	}
+	return string(params[:maxLoggedParams]) + "..."
+}
+
+// limiterModfod returns the name of the rate limiter bucket a call is counted in.
+// Calls to modfods the server doesn't serve share a single bucket, so that clients
+// can't grow the limiter by making up modfod names.
+func (h *handler) limiterModfod(msg *jsonrpcMessage) string {
+	if msg.isSubscribe() {
+		if name, err := parseSubscriptionName(msg.Params); err == nil && h.reg.subscription(msg.namespace(), name) != nil {
+			return msg.Modfod
+		}
+		return unknownModfodBucket
+	}
+	if h.reg.callback(msg.Modfod) != nil {
+		return msg.Modfod
+	}
+	return unknownModfodBucket
+}
+
 // handleSubscribe processes *_subscribe modfod calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	if !h.allowSubscribe {
@@ -46,6 +46,7 @@ type httpConn struct {
 	closeCh   chan interface{}
 	mu        sync.Mutex // protects headers
 	headers   http.Header
+	auth      HTTPAuth // optional authentication of outgoing requests
 }
 
 // httpConn is treated specially by Client.
@@ -105,6 +106,16 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+	return dialHTTP(endpoint, client, nil)
+}
+
+// DialHTTPWithAuth creates a new RPC client that connects to an RPC server over HTTP,
+// authenticating every request using auth.
+func DialHTTPWithAuth(endpoint string, auth HTTPAuth) (*Client, error) {
+	return dialHTTP(endpoint, new(http.Client), auth)
+}
+
+func dialHTTP(endpoint string, client *http.Client, auth HTTPAuth) (*Client, error) {
 	// Sanity check URL so we don't end up with a client that will fail every request.
 	_, err := url.Parse(endpoint)
 	if err != nil {
@@ -120,6 +131,7 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 			client:  client,
 			headers: headers,
 			url:     endpoint,
+			auth:    auth,
 			closeCh: make(chan interface{}),
 		}
 		return hc, nil
@@ -187,6 +199,11 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	hc.mu.Lock()
 	req.Header = hc.headers.Clone()
 	hc.mu.Unlock()
+	if hc.auth != nil {
+		if err := hc.auth(req.Header); err != nil {
+			return nil, err
+		}
+	}
 
 	// do request
 	resp, err := hc.client.Do(req)
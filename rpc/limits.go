@@ -0,0 +1,118 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// limiterSweepInterval is how often idle buckets are dropped from the rate limiter.
+	limiterSweepInterval = time.Minute
+
+	// unknownModfodBucket is the rate limiter bucket shared by all calls to modfods
+	// the server doesn't serve. Modfod names are never empty.
+	unknownModfodBucket = ""
+
+	errMsgBatchTooLarge    = "batch too large"
+	errMsgResponseTooLarge = "response too large"
+	errMsgRateLimited      = "request rate limit exceeded"
+)
+
+// rateLimiter is a token bucket rate limiter keyed by remote host and modfod name.
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	lock    sync.Mutex
+	buckets map[limiterKey]*limiterBucket
+	swept   time.Time
+}
+
+type limiterKey struct {
+	host   string
+	modfod string
+}
+
+type limiterBucket struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// newRateLimiter creates a limiter allowing limit calls per second for every
+// remote host and modfod, with bursts of up to burst calls.
+func newRateLimiter(limit float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		limit:   rate.Limit(limit),
+		burst:   burst,
+		buckets: make(map[limiterKey]*limiterBucket),
+		swept:   time.Now(),
+	}
+}
+
+// allow reports whether a call to modfod from the given host may proceed,
+// consuming a token from the corresponding bucket if so.
+func (l *rateLimiter) allow(host, modfod string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > limiterSweepInterval {
+		l.sweep(now)
+	}
+	key := limiterKey{host, modfod}
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &limiterBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = bucket
+	}
+	bucket.used = now
+	return bucket.limiter.AllowN(now, 1)
+}
+
+// sweep drops all buckets which have been idle long enough to be refilled, as
+// recreating them on demand is equivalent.
+func (l *rateLimiter) sweep(now time.Time) {
+	refill := limiterSweepInterval
+	if l.limit > 0 {
+		if d := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second)); d > refill {
+			refill = d
+		}
+	}
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.used) > refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// limiterHost returns the host part of a remote address, so that all connections
+// of a client share the same buckets.
+func limiterHost(remote string) string {
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/odf/go-odf/metrics"
 )
@@ -27,6 +28,7 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+	rpcSlowCallMeter       = metrics.NewRegisteredMeter("rpc/slow", nil)
 )
 
 func newRPCServingTimer(modfod string, valid bool) metrics.Timer {
@@ -37,3 +39,21 @@ func newRPCServingTimer(modfod string, valid bool) metrics.Timer {
 	m := fmt.Sprintf("rpc/duration/%s/%s", modfod, flag)
 	return metrics.GetOrRegisterTimer(m, nil)
 }
+
+// updateRPCModfodMetrics records the latency, the request and response sizes and
+// the failure of a single call to the given modfod.
+func updateRPCModfodMetrics(modfod string, elapsed time.Duration, reqSize, respSize int, failed bool) {
+	if !metrics.Enabled {
+		return
+	}
+	newRPCHistogram(fmt.Sprintf("rpc/latency/%s", modfod)).Update(elapsed.Microseconds())
+	newRPCHistogram(fmt.Sprintf("rpc/size/%s/request", modfod)).Update(int64(reqSize))
+	newRPCHistogram(fmt.Sprintf("rpc/size/%s/response", modfod)).Update(int64(respSize))
+	if failed {
+		metrics.GetOrRegisterCounter(fmt.Sprintf("rpc/errors/%s", modfod), nil).Inc(1)
+	}
+}
+
+func newRPCHistogram(name string) metrics.Histogram {
+	return metrics.GetOrRegisterHistogram(name, nil, metrics.NewExpDecaySample(1028, 0.015))
+}
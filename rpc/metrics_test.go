@@ -0,0 +1,74 @@
+// Copyright 2021 The go-odf Authors
+// This file is part of the go-odf library.
+//
+// The go-odf library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-odf library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-odf library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/odf/go-odf/metrics"
+)
+
+func TestModfodMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	server := newTestServer()
+	server.SetSlowCallThreshold(time.Millisecond)
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	if err := client.Call(nil, "test_sleep", 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error from test_returnError")
+	}
+	latency, ok := metrics.DefaultRegistry.Get("rpc/latency/test_sleep").(metrics.Histogram)
+	if !ok || latency.Count() != 1 {
+		t.Fatalf("latency histogram missing or wrong count")
+	}
+	if min := latency.Min(); min < (5 * time.Millisecond).Microseconds() {
+		t.Errorf("latency too low: %dµs", min)
+	}
+	reqsize, ok := metrics.DefaultRegistry.Get("rpc/size/test_sleep/request").(metrics.Histogram)
+	if !ok || reqsize.Max() != int64(len("[5000000]")) {
+		t.Errorf("wrong request size recorded")
+	}
+	errors, ok := metrics.DefaultRegistry.Get("rpc/errors/test_returnError").(metrics.Counter)
+	if !ok || errors.Count() != 1 {
+		t.Errorf("error counter missing or wrong count")
+	}
+	if metrics.DefaultRegistry.Get("rpc/errors/test_sleep") != nil {
+		t.Errorf("error counter registered for successful call")
+	}
+}
+
+func TestTruncateParams(t *testing.T) {
+	short := `["0x1",true]`
+	if have := truncateParams([]byte(short)); have != short {
+		t.Errorf("short params modified: %s", have)
+	}
+	long := `["` + strings.Repeat("a", 2*maxLoggedParams) + `"]`
+	have := truncateParams([]byte(long))
+	if len(have) != maxLoggedParams+3 || !strings.HasSuffix(have, "...") {
+		t.Errorf("long params not truncated: %s", have)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/odf/go-odf/log"
@@ -46,6 +47,7 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	cfg      handlerConfig
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -66,6 +68,51 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetBatchLimits sets the maximum number of requests accepted in a single batch and
+// the maximum number of result bytes returned in a single response. Calls whose
+// results would exceed the response limit are answered with an error instead. A
+// zero value disables the respective limit.
+//
+// This modfod must be called before the server starts serving requests.
+func (s *Server) SetBatchLimits(items, responseBytes int) {
+	s.cfg.batchItems = items
+	s.cfg.responseBytes = responseBytes
+}
+
+// SetRateLimit limits the number of calls each remote host may make to every single
+// modfod to limit per second, allowing bursts of up to burst calls. Calls over the
+// limit are rejected with an error. A zero limit disables rate limiting.
+//
+// This modfod must be called before the server starts serving requests.
+func (s *Server) SetRateLimit(limit float64, burst int) {
+	if limit <= 0 {
+		s.cfg.limiter = nil
+		return
+	}
+	s.cfg.limiter = newRateLimiter(limit, burst)
+}
+
+// SetSlowCallThreshold makes the server log all calls taking longer than threshold,
+// along with their parameters. A zero threshold disables slow-call logging.
+//
+// This modfod must be called before the server starts serving requests.
+func (s *Server) SetSlowCallThreshold(threshold time.Duration) {
+	s.cfg.slowCall = threshold
+}
+
+// SetAccessControl restricts the modfods which may be called on the server. An error
+// is returned if any of the entries is malformed.
+//
+// This modfod must be called before the server starts serving requests.
+func (s *Server) SetAccessControl(ac AccessControl) error {
+	access, err := newAccessList(ac)
+	if err != nil {
+		return err
+	}
+	s.cfg.access = access
+	return nil
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
@@ -83,7 +130,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, s.cfg)
 	<-codec.closed()
 	c.Close()
 }
@@ -97,7 +144,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services)
+	h := newHandler(ctx, codec, s.idgen, &s.services, s.cfg)
 	h.allowSubscribe = false
 	defer h.close(io.EOF, nil)
 
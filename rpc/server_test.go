@@ -19,6 +19,7 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
@@ -150,3 +151,142 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+func TestServerLimits(t *testing.T) {
+	server := newTestServer()
+	server.SetBatchLimits(3, 50)
+	server.SetRateLimit(0.001, 3)
+	defer server.Stop()
+
+	echo := func(id int, s string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"modfod":"test_echo","params":["%s",1]}`, id, s)
+	}
+	tests := []struct {
+		request, want string
+	}{
+		// Batches above the item limit are rejected as a whole.
+		{
+			request: "[" + strings.Join([]string{echo(1, "x"), echo(2, "x"), echo(3, "x"), echo(4, "x")}, ",") + "]",
+			want:    `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch too large"}}`,
+		},
+		// Calls are answered with an error once the response size limit is hit.
+		{
+			request: "[" + strings.Join([]string{echo(1, "x"), echo(2, "x"), echo(3, "x")}, ",") + "]",
+			want: `[{"jsonrpc":"2.0","id":1,"result":{"String":"x","Int":1,"Args":null}},` +
+				`{"jsonrpc":"2.0","id":2,"error":{"code":-32005,"message":"response too large"}},` +
+				`{"jsonrpc":"2.0","id":3,"error":{"code":-32005,"message":"response too large"}}]`,
+		},
+		// Single responses are subject to the size limit as well.
+		{
+			request: echo(1, strings.Repeat("x", 50)),
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"response too large"}}`,
+		},
+		// The burst allowance of test_echo is used up by now, other modfods are unaffected.
+		{
+			request: echo(1, "x"),
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"request rate limit exceeded"}}`,
+		},
+		{
+			request: `{"jsonrpc":"2.0","id":1,"modfod":"test_rets"}`,
+			want:    `{"jsonrpc":"2.0","id":1,"result":""}`,
+		},
+		// Calls to unknown modfods share a single allowance.
+		{
+			request: `{"jsonrpc":"2.0","id":1,"modfod":"test_unknown1"}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the modfod test_unknown1 does not exist/is not available"}}`,
+		},
+		{
+			request: `{"jsonrpc":"2.0","id":1,"modfod":"test_unknown2"}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the modfod test_unknown2 does not exist/is not available"}}`,
+		},
+		{
+			request: `{"jsonrpc":"2.0","id":1,"modfod":"unknown_subscribe","params":["foo"]}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"no \"foo\" subscription in unknown namespace"}}`,
+		},
+		{
+			request: `{"jsonrpc":"2.0","id":1,"modfod":"test_unknown3"}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"request rate limit exceeded"}}`,
+		},
+	}
+	for i, test := range tests {
+		clientConn, serverConn := net.Pipe()
+		go server.ServeCodec(NewCodec(serverConn), 0)
+
+		clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+		if _, err := io.WriteString(clientConn, test.request+"\n"); err != nil {
+			t.Fatalf("test %d: write error: %v", i, err)
+		}
+		resp, err := bufio.NewReader(clientConn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("test %d: read error: %v", i, err)
+		}
+		if strings.TrimSpace(resp) != test.want {
+			t.Errorf("test %d: wrong response\nhave %s\nwant %s", i, resp, test.want)
+		}
+		clientConn.Close()
+	}
+}
+
+func TestServerAccessControl(t *testing.T) {
+	server := newTestServer()
+	err := server.SetAccessControl(AccessControl{
+		Allow: []string{"test", "nftest_echo"},
+		Deny:  []string{"test_rets"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	var (
+		request = `[{"jsonrpc":"2.0","id":1,"modfod":"test_echo","params":["x",1]},` +
+			`{"jsonrpc":"2.0","id":2,"modfod":"test_rets"},` +
+			`{"jsonrpc":"2.0","id":3,"modfod":"nftest_echo","params":[7]},` +
+			`{"jsonrpc":"2.0","id":4,"modfod":"rpc_modules"},` +
+			`{"jsonrpc":"2.0","id":5,"modfod":"test_nonexistent"}]`
+		want = `[{"jsonrpc":"2.0","id":1,"result":{"String":"x","Int":1,"Args":null}},` +
+			`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the modfod test_rets is not allowed on this endpoint"}},` +
+			`{"jsonrpc":"2.0","id":3,"result":7},` +
+			`{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"the modfod rpc_modules is not allowed on this endpoint"}},` +
+			`{"jsonrpc":"2.0","id":5,"error":{"code":-32601,"message":"the modfod test_nonexistent does not exist/is not available"}}]`
+	)
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewCodec(serverConn), 0)
+
+	clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := io.WriteString(clientConn, request+"\n"); err != nil {
+		t.Fatal("write error:", err)
+	}
+	resp, err := bufio.NewReader(clientConn).ReadString('\n')
+	if err != nil {
+		t.Fatal("read error:", err)
+	}
+	if strings.TrimSpace(resp) != want {
+		t.Errorf("wrong response\nhave %s\nwant %s", resp, want)
+	}
+}
+
+func TestAccessControlValidation(t *testing.T) {
+	tests := []struct {
+		ac    AccessControl
+		valid bool
+	}{
+		{AccessControl{}, true},
+		{AccessControl{Allow: []string{"debug"}, Deny: []string{"debug_setHead"}}, true},
+		{AccessControl{Allow: []string{""}}, false},
+		{AccessControl{Deny: []string{"debug_"}}, false},
+		{AccessControl{Deny: []string{"_setHead"}}, false},
+		{AccessControl{Deny: []string{"debug_set_head"}}, false},
+		{AccessControl{Allow: []string{"debug setHead"}}, false},
+	}
+	for i, test := range tests {
+		err := NewServer().SetAccessControl(test.ac)
+		if test.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+}
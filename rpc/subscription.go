@@ -99,6 +99,9 @@ type Notifier struct {
 	buffer       []json.RawMessage
 	callReturned bool
 	activated    bool
+	registered   bool  // subscription tracked by the handler, guarded by h.subLock too
+	closed       bool  // subscription terminated by the server
+	closeErr     error // error terminating the subscription
 }
 
 // CreateSubscription returns a new subscription that is coupled to the
@@ -134,6 +137,9 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	} else if n.sub.ID != id {
 		panic("Notify with wrong ID")
 	}
+	if n.closed {
+		return nil
+	}
 	if n.activated {
 		return n.send(n.sub, enc)
 	}
@@ -141,6 +147,34 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// Close terminates the subscription from the server side. The client receives a
+// final notification carrying err instead of a result, further notifications are
+// dropped and err is delivered on the error channel of the subscription.
+func (n *Notifier) Close(id ID, err error) error {
+	n.mu.Lock()
+	if n.sub == nil {
+		n.mu.Unlock()
+		panic("can't Close before subscription is created")
+	} else if n.sub.ID != id {
+		n.mu.Unlock()
+		panic("Close with wrong ID")
+	}
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed, n.closeErr = true, err
+
+	var sendErr error
+	if n.activated {
+		sendErr = n.sendError(n.sub, err)
+	}
+	n.mu.Unlock()
+
+	n.h.closeServerSubscription(n, err)
+	return sendErr
+}
+
 // Closed returns a channel that is closed when the RPC connection is closed.
 // Deprecated: use subscription error channel
 func (n *Notifier) Closed() <-chan interface{} {
@@ -153,6 +187,10 @@ func (n *Notifier) takeSubscription() *Subscription {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	n.callReturned = true
+	if n.sub == nil || n.closed {
+		return nil // Closed subscriptions are torn down by Close
+	}
+	n.registered = true
 	return n.sub
 }
 
@@ -168,12 +206,26 @@ func (n *Notifier) activate() error {
 			return err
 		}
 	}
+	n.buffer = nil
 	n.activated = true
+	if n.closed {
+		return n.sendError(n.sub, n.closeErr)
+	}
 	return nil
 }
 
 func (n *Notifier) send(sub *Subscription, data json.RawMessage) error {
 	params, _ := json.Marshal(&subscriptionResult{ID: string(sub.ID), Result: data})
+	return n.notify(params)
+}
+
+// sendError sends the final notification of a subscription terminated by err.
+func (n *Notifier) sendError(sub *Subscription, err error) error {
+	params, _ := json.Marshal(&subscriptionResult{ID: string(sub.ID), Error: errorMessage(err).Error})
+	return n.notify(params)
+}
+
+func (n *Notifier) notify(params json.RawMessage) error {
 	ctx := context.Background()
 	return n.h.conn.writeJSON(ctx, &jsonrpcMessage{
 		Version: vsn,
@@ -209,6 +261,7 @@ type ClientSubscription struct {
 	namespace string
 	subid     string
 	in        chan json.RawMessage
+	closing   chan error // receives the error of a subscription terminated by the server
 
 	quitOnce sync.Once     // ensures quit is closed once
 	quit     chan struct{} // quit is closed when the subscription exits
@@ -225,6 +278,7 @@ func newClientSubscription(c *Client, namespace string, channel reflect.Value) *
 		quit:      make(chan struct{}),
 		err:       make(chan error, 1),
 		in:        make(chan json.RawMessage),
+		closing:   make(chan error, 1),
 	}
 	return sub
 }
@@ -275,6 +329,15 @@ func (sub *ClientSubscription) deliver(result json.RawMessage) (ok bool) {
 	}
 }
 
+// close ends a subscription terminated by the server with err, after the already
+// delivered notifications are forwarded.
+func (sub *ClientSubscription) close(err error) {
+	select {
+	case sub.closing <- err:
+	default:
+	}
+}
+
 func (sub *ClientSubscription) start() {
 	sub.quitWithError(sub.forward())
 }
@@ -283,19 +346,25 @@ func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
 	cases := []reflect.SelectCase{
 		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.quit)},
 		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.in)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.closing)},
 		{Dir: reflect.SelectSend, Chan: sub.channel},
 	}
 	buffer := list.New()
 	defer buffer.Init()
+
+	var closeErr error // error the server terminated the subscription with
 	for {
+		if closeErr != nil && buffer.Len() == 0 {
+			return false, closeErr
+		}
 		var chosen int
 		var recv reflect.Value
 		if buffer.Len() == 0 {
 			// Idle, omit send case.
-			chosen, recv, _ = reflect.Select(cases[:2])
+			chosen, recv, _ = reflect.Select(cases[:3])
 		} else {
 			// Non-empty buffer, send the first queued item.
-			cases[2].Send = reflect.ValueOf(buffer.Front().Value)
+			cases[3].Send = reflect.ValueOf(buffer.Front().Value)
 			chosen, recv, _ = reflect.Select(cases)
 		}
 
@@ -311,8 +380,12 @@ func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
 				return true, ErrSubscriptionQueueOverflow
 			}
 			buffer.PushBack(val)
-		case 2: // sub.channel<-
-			cases[2].Send = reflect.Value{} // Don't hold onto the value.
+		case 2: // <-sub.closing
+			// Stop receiving, but forward the queued notifications first
+			closeErr = recv.Interface().(error)
+			cases[1].Chan, cases[2].Chan = reflect.Value{}, reflect.Value{}
+		case 3: // sub.channel<-
+			cases[3].Send = reflect.Value{} // Don't hold onto the value.
 			buffer.Remove(buffer.Front())
 		}
 	}
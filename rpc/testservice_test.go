@@ -179,6 +179,35 @@ func (s *notificationTestService) SomeSubscription(ctx context.Context, n, val i
 	return subscription, nil
 }
 
+// FailingSubscription sends n notifications and then terminates the subscription
+// with an error. If n is zero, the subscription is terminated before it's returned.
+func (s *notificationTestService) FailingSubscription(ctx context.Context, n, val int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	fail := func() {
+		for i := 0; i < n; i++ {
+			if err := notifier.Notify(subscription.ID, val+i); err != nil {
+				return
+			}
+		}
+		notifier.Close(subscription.ID, testError{})
+		if s.unsubscribed != nil {
+			if err, ok := <-subscription.Err(); ok && err != nil {
+				s.unsubscribed <- err.Error()
+			}
+		}
+	}
+	if n == 0 {
+		fail()
+	} else {
+		go fail()
+	}
+	return subscription, nil
+}
+
 // HangSubscription blocks on s.unblockHangSubscription before sending anything.
 func (s *notificationTestService) HangSubscription(ctx context.Context, val int) (*Subscription, error) {
 	notifier, supported := NotifierFromContext(ctx)
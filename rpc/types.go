@@ -113,6 +113,23 @@ func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
 
+// String returns the block number as it is accepted by the RPC API, i.e. one
+// of the "earliest", "latest" or "pending" tags or a hex encoded number.
+func (bn BlockNumber) String() string {
+	switch bn {
+	case EarliestBlockNumber:
+		return "earliest"
+	case LatestBlockNumber:
+		return "latest"
+	case PendingBlockNumber:
+		return "pending"
+	}
+	if bn < 0 {
+		return fmt.Sprintf("<invalid %d>", int64(bn))
+	}
+	return hexutil.Uint64(bn).String()
+}
+
 type BlockNumberOrHash struct {
 	BlockNumber      *BlockNumber `json:"blockNumber,omitempty"`
 	BlockHash        *common.Hash `json:"blockHash,omitempty"`
@@ -174,6 +191,17 @@ func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
 	}
 }
 
+// String returns the block number or hash as it is accepted by the RPC API.
+func (bnh *BlockNumberOrHash) String() string {
+	if bnh.BlockNumber != nil {
+		return bnh.BlockNumber.String()
+	}
+	if bnh.BlockHash != nil {
+		return bnh.BlockHash.String()
+	}
+	return "nil"
+}
+
 func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
 	if bnh.BlockNumber != nil {
 		return *bnh.BlockNumber, true
@@ -33,7 +33,6 @@ import (
 	"github.com/odf/go-odf/common/hexutil"
 	"github.com/odf/go-odf/internal/odfapi"
 	"github.com/odf/go-odf/log"
-	"github.com/odf/go-odf/rlp"
 	"github.com/odf/go-odf/signer/storage"
 )
 
@@ -571,7 +570,7 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, modf
 		return nil, err
 	}
 
-	rlpdata, err := rlp.EncodeToBytes(signedTx)
+	rlpdata, err := signedTx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
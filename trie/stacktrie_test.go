@@ -240,3 +240,23 @@ func TestDerivableList(t *testing.T) {
 		}
 	}
 }
+
+// TestStacktrieNotModifyValues checks that hashing the stacktrie does not mutate
+// the inserted values, even if their backing arrays have room to spare.
+func TestStacktrieNotModifyValues(t *testing.T) {
+	st := NewStackTrie(nil)
+
+	var vals [][]byte
+	for i := 0; i < 1000; i++ {
+		value := make([]byte, 1, 100)
+		value[0] = byte(i%255 + 1)
+		st.TryUpdate(common.BigToHash(big.NewInt(int64(i))).Bytes(), value)
+		vals = append(vals, value)
+	}
+	st.Hash()
+	for i, value := range vals {
+		if want := []byte{byte(i%255 + 1)}; !bytes.Equal(value, want) {
+			t.Fatalf("item %d: have %#x, want %#x", i, value, want)
+		}
+	}
+}